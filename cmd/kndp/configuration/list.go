@@ -3,22 +3,40 @@ package configuration
 import (
 	"context"
 
+	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"github.com/kndpio/kndp/internal/configuration"
-	"github.com/pterm/pterm"
+	"github.com/kndpio/kndp/internal/printer"
 	"go.uber.org/zap"
 
 	"k8s.io/client-go/dynamic"
 )
 
 type listCmd struct {
+	printer.Output
 }
 
-func (listCmd) Run(ctx context.Context, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+func (listCmd) Run(ctx context.Context, dynamicClient *dynamic.DynamicClient, p *printer.Printer, logger *zap.SugaredLogger) error {
 	configurations := configuration.GetConfigurations(ctx, dynamicClient)
-	table := pterm.TableData{{"NAME", "PACKAGE"}}
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "PACKAGE"},
+			{Header: "INSTALLED", Wide: true},
+			{Header: "HEALTHY", Wide: true},
+		},
+	}
 	for _, conf := range configurations {
-		table = append(table, []string{conf.Name, conf.Spec.Package})
+		conf := conf
+		table.Rows = append(table.Rows, printer.Row{
+			Name: conf.Name,
+			Cells: []string{
+				conf.Name,
+				conf.Spec.Package,
+				string(conf.GetCondition(crossv1.TypeInstalled).Status),
+				string(conf.GetCondition(crossv1.TypeHealthy).Status),
+			},
+			Object: &conf,
+		})
 	}
-	pterm.DefaultTable.WithHasHeader().WithData(table).Render()
-	return nil
+	return p.Print(table)
 }
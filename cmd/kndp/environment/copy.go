@@ -4,18 +4,72 @@ import (
 	"context"
 
 	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
 
 	"go.uber.org/zap"
 )
 
 type copyCmd struct {
-	Source       string `arg:"" required:"" help:"Name source of environment."`
-	Destination  string `arg:"" required:"" help:"Name destination of environment."`
-	SourceEngine string `arg:"" required:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	printer.Output
+
+	Source       string   `arg:"" required:"" help:"Name source of environment."`
+	Destination  string   `arg:"" required:"" help:"Name destination of environment."`
+	SourceEngine string   `arg:"" required:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	DryRun       bool     `optional:"" help:"List objects which would be created, updated, skipped or conflicting, without copying."`
+	Diff         bool     `optional:"" help:"Show field differences of engine values and composites existing in destination."`
+	Include      []string `optional:"" help:"Copy only objects matched by <kind>[:<name glob>], kinds: registry, engine, provider, configuration, composite."`
+	Exclude      []string `optional:"" help:"Do not copy objects matched by <kind>[:<name glob>]."`
+	Force        bool     `optional:"" help:"Copy even if objects conflict with destination, conflicting objects are skipped, and allow downgrade of Crossplane engine."`
 }
 
-func (c *copyCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
-	return environment.
+func (c *copyCmd) Run(ctx context.Context, p *printer.Printer, logger *zap.SugaredLogger) error {
+	env := environment.
 		New(c.Source, c.Source).
-		CopyEnvironment(ctx, logger, c.Source, c.Destination)
+		WithCopyFilter(environment.CopyFilter{Include: c.Include, Exclude: c.Exclude}).
+		WithForce(c.Force)
+	if c.DryRun || c.Diff {
+		plan, err := env.PlanCopy(ctx, c.Source, c.Destination)
+		if err != nil {
+			return err
+		}
+		err = c.printPlan(p, plan)
+		if err != nil {
+			return err
+		}
+		if c.DryRun {
+			return nil
+		}
+	}
+	return env.CopyEnvironment(ctx, logger, c.Source, c.Destination)
+}
+
+func (c *copyCmd) printPlan(p *printer.Printer, plan *environment.CopyPlan) error {
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "KIND"},
+			{Header: "NAME"},
+			{Header: "ACTION"},
+			{Header: "MESSAGE"},
+		},
+	}
+	for _, item := range plan.Items {
+		item := item
+		if !c.Diff {
+			item.Diff = nil
+		}
+		table.Rows = append(table.Rows, printer.Row{
+			Name:   item.Kind + "/" + item.Name,
+			Cells:  []string{item.Kind, item.Name, item.Action, item.Message},
+			Object: &item,
+		})
+	}
+	err := p.Print(table)
+	if err != nil || !c.Diff {
+		return err
+	}
+	objects := []objectDiff{}
+	for _, item := range plan.Items {
+		objects = append(objects, objectDiff{name: item.Kind + "/" + item.Name, diffs: item.Diff})
+	}
+	return printDiff(p, "OBJECT", objects)
 }
@@ -2,26 +2,126 @@ package environment
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/kndpio/kndp/internal/environment"
 	"go.uber.org/zap"
 )
 
 type createCmd struct {
-	Name      string `arg:"" requried:"" help:"Name of environment."`
-	HttpPort  int    `optional:"" short:"p" help:"Http host port for mapping" default:"80"`
-	HttpsPort int    `optional:"" short:"s" help:"Https host port for mapping" default:"443"`
-	Context   string `optional:"" short:"c" help:"Kubernetes context where Environment will be created."`
-	Engine    string `optional:"" short:"e" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
-	MountPath string `optional:"" help:"Path for mount to /storage host directory. By default no mounts."`
+	Name      string        `arg:"" optional:"" help:"Name of environment, required if not defined by spec file."`
+	File      string        `optional:"" short:"f" type:"existingfile" help:"Environment spec file, flags for engine, ports and mounts are ignored when provided."`
+	HttpPort  int           `optional:"" short:"p" help:"Http host port for mapping" default:"80"`
+	HttpsPort int           `optional:"" short:"s" help:"Https host port for mapping" default:"443"`
+	Context   string        `optional:"" short:"c" help:"Kubernetes context where Environment will be created."`
+	Engine    string        `optional:"" short:"e" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	MountPath string        `optional:"" help:"Path for mount to /storage host directory. By default no mounts."`
+	TTL       time.Duration `optional:"" name:"ttl" help:"Time to live of environment, expired environment is deleted by env gc, e.g. 8h."`
+
+	EngineVersion string            `optional:"" help:"Version of Crossplane engine chart, version supported by kndp is used by default."`
+	Values        []string          `optional:"" help:"Engine Helm values file, could be repeated, later files take precedence."`
+	Set           map[string]string `optional:"" mapsep:"none" help:"Engine Helm value in format <key>=<value>, could be repeated, takes precedence over values files."`
+
+	Ingress string `optional:"" help:"Ingress controller installed to environment: nginx, traefik or none."`
+	Policy  string `optional:"" help:"Policy controller installed to environment: kyverno or none."`
+
+	WithKubernetesProvider bool `optional:"" help:"Install provider-kubernetes configured to manage environment cluster."`
+	WithHelmProvider       bool `optional:"" help:"Install provider-helm configured to manage environment cluster."`
+	ProvidersClusterAdmin  bool `optional:"" help:"Grant all permissions to Kubernetes and Helm providers, instead of Crossplane admin role."`
+
+	ControlPlanes     int      `optional:"" help:"Number of control plane nodes." default:"1"`
+	Workers           int      `optional:"" help:"Number of worker nodes." default:"1"`
+	NodeImage         string   `optional:"" help:"Image of cluster nodes, overrides Kubernetes version."`
+	KubernetesVersion string   `optional:"" help:"Kubernetes version of cluster nodes."`
+	NodeLabel         []string `optional:"" help:"Node label in format <role>[.<index>]:<key>=<value>, role is control-plane or worker."`
+	NodeTaint         []string `optional:"" help:"Node taint in format <role>[.<index>]:<key>[=<value>]:<effect>, replaces default taints of node."`
 }
 
 func (c *createCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
-	return environment.
-		New(c.Engine, c.Name).
-		WithHttpPort(c.HttpPort).
-		WithHttpsPort(c.HttpsPort).
-		WithContext(c.Context).
-		WithMountPath(c.MountPath).
+	env, err := c.environment()
+	if err != nil {
+		return err
+	}
+	if c.EngineVersion != "" {
+		env.WithEngineVersion(c.EngineVersion)
+	}
+	if c.Ingress != "" {
+		env.WithIngressController(c.Ingress)
+	}
+	if c.Policy != "" {
+		env.WithPolicyController(c.Policy)
+	}
+	if c.WithKubernetesProvider || c.WithHelmProvider {
+		env.WithPrivilegedProviders(environment.PrivilegedProvidersSpec{
+			Kubernetes:   c.WithKubernetesProvider,
+			Helm:         c.WithHelmProvider,
+			ClusterAdmin: c.ProvidersClusterAdmin,
+		})
+	}
+	return env.
+		WithTTL(c.TTL).
+		WithValueFiles(c.Values...).
+		WithOverrides(c.Set).
 		Create(ctx, logger)
 }
+
+// Environment from spec file or from command flags
+func (c *createCmd) environment() (*environment.Environment, error) {
+	if c.File == "" {
+		if c.Name == "" {
+			return nil, fmt.Errorf("name of environment is required")
+		}
+		topology, err := c.topology()
+		if err != nil {
+			return nil, err
+		}
+		return environment.
+			New(c.Engine, c.Name).
+			WithHttpPort(c.HttpPort).
+			WithHttpsPort(c.HttpsPort).
+			WithContext(c.Context).
+			WithMountPath(c.MountPath).
+			WithTopology(topology), nil
+	}
+
+	spec, err := environment.LoadSpec(c.File)
+	if err != nil {
+		return nil, err
+	}
+	if c.Name != "" {
+		spec.Metadata.Name = c.Name
+	}
+	if c.Context != "" {
+		spec.Spec.Context = c.Context
+	}
+	if spec.Metadata.Name == "" {
+		return nil, fmt.Errorf("name of environment is required by argument or spec metadata")
+	}
+	return spec.Environment(), nil
+}
+
+// Nodes topology from command flags
+func (c *createCmd) topology() (environment.Topology, error) {
+	topology := environment.Topology{
+		ControlPlanes:     c.ControlPlanes,
+		Workers:           &c.Workers,
+		Image:             c.NodeImage,
+		KubernetesVersion: c.KubernetesVersion,
+	}
+	for _, l := range c.NodeLabel {
+		node, err := environment.ParseNodeLabel(l)
+		if err != nil {
+			return topology, err
+		}
+		topology.Nodes = append(topology.Nodes, node)
+	}
+	for _, t := range c.NodeTaint {
+		node, err := environment.ParseNodeTaint(t)
+		if err != nil {
+			return topology, err
+		}
+		topology.Nodes = append(topology.Nodes, node)
+	}
+	return topology, topology.Validate()
+}
@@ -0,0 +1,29 @@
+package environment
+
+import (
+	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
+)
+
+type currentCmd struct {
+	printer.Output
+}
+
+func (c *currentCmd) Run(p *printer.Printer) error {
+	current, err := environment.Current()
+	if err != nil {
+		return err
+	}
+	return p.Print(printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "ENGINE"},
+			{Header: "CONTEXT"},
+		},
+		Rows: []printer.Row{{
+			Name:   current.Name,
+			Cells:  []string{current.Name, current.Engine, current.Context},
+			Object: current,
+		}},
+	})
+}
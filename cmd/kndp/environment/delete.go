@@ -2,19 +2,29 @@ package environment
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/kndpio/kndp/internal/environment"
 	"go.uber.org/zap"
 )
 
 type deleteCmd struct {
-	Name    string `arg:"" required:"" help:"Name of environment."`
-	Engine  string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
-	Confirm bool   `optional:"" short:"c" help:"Confirm deletion of kndp environment." default:"false"`
+	Name      string `arg:"" optional:"" help:"Name of environment, required if context is not provided."`
+	Engine    string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	Context   string `optional:"" help:"Kubernetes context of existing cluster, engine and kndp resources are removed from it and cluster is kept."`
+	Confirm   bool   `optional:"" short:"c" help:"Confirm deletion of kndp environment." default:"false"`
+	Uninstall bool   `optional:"" help:"Uninstall engine from host, supported by k3s engine."`
+	MountPath string `optional:"" help:"Mount path used on create, k3s data directory to be cleaned."`
 }
 
 func (c *deleteCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	if c.Name == "" && c.Context == "" {
+		return fmt.Errorf("name or context of environment is required")
+	}
 	return environment.
 		New(c.Engine, c.Name).
-		Delete(c.Confirm, logger)
+		WithContext(c.Context).
+		WithUninstall(c.Uninstall).
+		WithMountPath(c.MountPath).
+		Delete(ctx, c.Confirm, logger)
 }
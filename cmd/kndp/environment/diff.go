@@ -0,0 +1,42 @@
+package environment
+
+import (
+	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
+)
+
+// Field differences of object
+type objectDiff struct {
+	name  string
+	diffs []environment.FieldDiff
+}
+
+// Print field differences of objects as table, removed values are destination and added are source.
+// Structured formats already include differences in printed objects.
+func printDiff(p *printer.Printer, header string, objects []objectDiff) error {
+	if p.Structured() {
+		return nil
+	}
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: header},
+			{Header: "FIELD"},
+			{Header: "REMOVED"},
+			{Header: "ADDED"},
+		},
+	}
+	for _, object := range objects {
+		for _, diff := range object.diffs {
+			diff := diff
+			table.Rows = append(table.Rows, printer.Row{
+				Name:   object.name + "/" + diff.Path,
+				Cells:  []string{object.name, diff.Path, diff.Destination, diff.Source},
+				Object: &diff,
+			})
+		}
+	}
+	if len(table.Rows) == 0 {
+		return nil
+	}
+	return p.Print(table)
+}
@@ -1,11 +1,23 @@
 package environment
 
 type Cmd struct {
-	Create  createCmd  `cmd:"" help:"Create an Environment"`
-	Delete  deleteCmd  `cmd:"" help:"Delete an Environment"`
-	Copy    copyCmd    `cmd:"" help:"Copy an Environment to another destination context"`
-	List    listCmd    `cmd:"" help:"List of Environments"`
-	Stop    stopCmd    `cmd:"" help:"Stop an Environment"`
-	Start   startCmd   `cmd:"" help:"Start an Environment"`
-	Upgrade upgradeCmd `cmd:"" help:"Upgrade specified environment context with the latest engine"`
+	Create   createCmd   `cmd:"" help:"Create an Environment"`
+	Delete   deleteCmd   `cmd:"" help:"Delete an Environment"`
+	Copy     copyCmd     `cmd:"" help:"Copy an Environment to another destination context"`
+	List     listCmd     `cmd:"" help:"List of Environments"`
+	Stop     stopCmd     `cmd:"" help:"Stop an Environment"`
+	Start    startCmd    `cmd:"" help:"Start an Environment"`
+	Restart  restartCmd  `cmd:"" help:"Restart an Environment"`
+	Upgrade  upgradeCmd  `cmd:"" help:"Upgrade engine version or options of specified environment context"`
+	Status   statusCmd   `cmd:"" help:"Show health status of an Environment"`
+	Export   exportCmd   `cmd:"" help:"Export an Environment to archive"`
+	Import   importCmd   `cmd:"" help:"Import an Environment from archive to context"`
+	Versions versionsCmd `cmd:"" help:"List Crossplane engine versions available for Environments"`
+	History  historyCmd  `cmd:"" help:"Show revisions of Environment engine release"`
+	Rollback rollbackCmd `cmd:"" help:"Roll back Environment engine release to previous or specified revision"`
+
+	Use        useCmd        `cmd:"" help:"Switch current kubeconfig context to an Environment"`
+	Current    currentCmd    `cmd:"" help:"Show Environment of current kubeconfig context"`
+	Kubeconfig kubeconfigCmd `cmd:"" help:"Print or export kubeconfig of an Environment"`
+	Gc         gcCmd         `cmd:"" help:"Delete expired and orphaned Environments"`
 }
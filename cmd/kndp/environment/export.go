@@ -0,0 +1,18 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"go.uber.org/zap"
+)
+
+type exportCmd struct {
+	Context       string `arg:"" required:"" help:"Kubernetes context of Environment to export."`
+	Output        string `optional:"" short:"o" help:"Path of environment archive." default:"env.tgz" type:"path"`
+	RedactSecrets bool   `optional:"" help:"Remove registry credentials from archive."`
+}
+
+func (c *exportCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	return environment.ExportEnvironment(ctx, c.Context, c.Output, c.RedactSecrets, logger)
+}
@@ -0,0 +1,51 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
+	"go.uber.org/zap"
+)
+
+type gcCmd struct {
+	printer.Output
+
+	DryRun  bool `optional:"" help:"Only list environments which would be deleted."`
+	Confirm bool `optional:"" short:"c" help:"Confirm deletion of environments, clusters without contexts are still confirmed by prompt." default:"false"`
+}
+
+func (c *gcCmd) Run(ctx context.Context, p *printer.Printer, logger *zap.SugaredLogger) error {
+	garbage, err := environment.FindGarbage(ctx, logger)
+	if err != nil {
+		return err
+	}
+	if len(garbage) == 0 {
+		logger.Info("No expired or orphaned environments found.")
+		return nil
+	}
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "ENGINE"},
+			{Header: "CONTEXT"},
+			{Header: "ACTION"},
+			{Header: "REASON"},
+		},
+	}
+	for _, item := range garbage {
+		item := item
+		table.Rows = append(table.Rows, printer.Row{
+			Name:   item.Name,
+			Cells:  []string{item.Name, item.Engine, item.Context, item.Action, item.Reason},
+			Object: &item,
+		})
+	}
+	if err := p.Print(table); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+	return environment.CollectGarbage(ctx, garbage, c.Confirm, logger)
+}
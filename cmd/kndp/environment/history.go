@@ -0,0 +1,57 @@
+package environment
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
+)
+
+type historyCmd struct {
+	printer.Output
+
+	Name    string `arg:"" required:"" help:"Name of environment."`
+	Engine  string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	Context string `optional:"" short:"c" help:"Kubernetes context of Environment."`
+	Diff    bool   `optional:"" help:"Show engine values changed by each revision."`
+}
+
+func (c *historyCmd) Run(ctx context.Context, p *printer.Printer) error {
+	revisions, err := environment.
+		New(c.Engine, c.Name).
+		WithContext(c.Context).
+		History(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "REVISION"},
+			{Header: "UPDATED"},
+			{Header: "STATUS"},
+			{Header: "VERSION"},
+			{Header: "CHANGED VALUES"},
+			{Header: "APP VERSION", Wide: true},
+			{Header: "DESCRIPTION", Wide: true},
+		},
+	}
+	for _, r := range revisions {
+		r := r
+		table.Rows = append(table.Rows, printer.Row{
+			Name:   strconv.Itoa(r.Revision),
+			Cells:  []string{strconv.Itoa(r.Revision), r.Updated.Format("2006-01-02 15:04:05"), r.Status, r.Version, strconv.Itoa(len(r.Diff)), r.AppVersion, r.Description},
+			Object: &r,
+		})
+	}
+	err = p.Print(table)
+	if err != nil || !c.Diff {
+		return err
+	}
+	objects := []objectDiff{}
+	for _, r := range revisions {
+		objects = append(objects, objectDiff{name: strconv.Itoa(r.Revision), diffs: r.Diff})
+	}
+	return printDiff(p, "REVISION", objects)
+}
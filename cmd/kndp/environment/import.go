@@ -0,0 +1,17 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"go.uber.org/zap"
+)
+
+type importCmd struct {
+	File    string `arg:"" required:"" help:"Path of environment archive." type:"existingfile"`
+	Context string `optional:"" short:"c" help:"Kubernetes context to import Environment to, current context by default."`
+}
+
+func (c *importCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	return environment.ImportEnvironment(ctx, c.File, c.Context, logger)
+}
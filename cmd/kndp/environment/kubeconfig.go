@@ -0,0 +1,27 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"go.uber.org/zap"
+)
+
+type kubeconfigCmd struct {
+	Name   string `arg:"" required:"" help:"Name of environment."`
+	Engine string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	Export string `optional:"" type:"path" help:"Path of file where kubeconfig is exported, printed to stdout by default."`
+}
+
+func (c *kubeconfigCmd) Run(logger *zap.SugaredLogger) error {
+	env := environment.New(c.Engine, c.Name)
+	if c.Export != "" {
+		return env.ExportKubeconfig(c.Export, logger)
+	}
+	data, err := env.Kubeconfig()
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -2,21 +2,58 @@ package environment
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
 
-	"github.com/pterm/pterm"
 	"go.uber.org/zap"
 )
 
 type listCmd struct {
-}
+	printer.Output
 
-func (c *listCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
-	logger.Info("helo from run list")
-	tableData := pterm.TableData{{"NAME", "TYPE"}}
-	tableData = environment.ListEnvironments(logger, tableData)
-	pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	Timeout time.Duration `optional:"" short:"t" help:"Timeout of querying each context." default:"5s"`
+}
 
-	return nil
+func (c *listCmd) Run(ctx context.Context, p *printer.Printer, logger *zap.SugaredLogger) error {
+	environments, err := environment.ListEnvironments(ctx, c.Timeout)
+	if err != nil {
+		return err
+	}
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "CURRENT"},
+			{Header: "NAME"},
+			{Header: "ENGINE"},
+			{Header: "STATUS"},
+			{Header: "KUBERNETES"},
+			{Header: "CROSSPLANE"},
+			{Header: "PROVIDERS"},
+			{Header: "CONFIGURATIONS"},
+			{Header: "MESSAGE", Wide: true},
+		},
+	}
+	for _, env := range environments {
+		env := env
+		current := ""
+		if env.Current {
+			current = "*"
+		}
+		providers, configurations := "", ""
+		if env.Status == environment.StatusRunning {
+			providers = strconv.Itoa(env.Providers)
+			configurations = strconv.Itoa(env.Configurations)
+		}
+		table.Rows = append(table.Rows, printer.Row{
+			Name:   env.Name,
+			Cells:  []string{current, env.Name, env.Engine, string(env.Status), env.KubernetesVersion, env.CrossplaneVersion, providers, configurations, env.Message},
+			Object: &env,
+		})
+		if env.Status == environment.StatusUnreachable {
+			logger.Debugf("Context %s is unreachable: %s", env.Name, env.Message)
+		}
+	}
+	return p.Print(table)
 }
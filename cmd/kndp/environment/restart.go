@@ -0,0 +1,20 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"go.uber.org/zap"
+)
+
+type restartCmd struct {
+	Name   string `arg:"" required:"" help:"Name of environment."`
+	Switch bool   `optional:"" short:"s" help:"Switch kubernetes context to restarted cluster context."`
+	Engine string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+}
+
+func (c *restartCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	return environment.
+		New(c.Engine, c.Name).
+		Restart(ctx, c.Switch, logger)
+}
@@ -0,0 +1,22 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"go.uber.org/zap"
+)
+
+type rollbackCmd struct {
+	Name     string `arg:"" required:"" help:"Name of environment."`
+	Engine   string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	Context  string `optional:"" short:"c" help:"Kubernetes context of Environment."`
+	Revision int    `optional:"" short:"r" help:"Revision of engine release to roll back to, previous revision by default."`
+}
+
+func (c *rollbackCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
+	return environment.
+		New(c.Engine, c.Name).
+		WithContext(c.Context).
+		Rollback(ctx, c.Revision, logger)
+}
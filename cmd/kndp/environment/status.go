@@ -0,0 +1,60 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/printer"
+	"go.uber.org/zap"
+)
+
+type statusCmd struct {
+	printer.Output
+
+	Name    string `arg:"" required:"" help:"Name of environment."`
+	Engine  string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+	Context string `optional:"" short:"c" help:"Kubernetes context of Environment."`
+}
+
+func (c *statusCmd) Run(ctx context.Context, p *printer.Printer, logger *zap.SugaredLogger) error {
+	health, err := environment.
+		New(c.Engine, c.Name).
+		WithContext(c.Context).
+		Health(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "KIND"},
+			{Header: "NAME"},
+			{Header: "STATUS"},
+			{Header: "HEALTHY"},
+			{Header: "MESSAGE", Wide: true},
+		},
+	}
+	unhealthy := 0
+	for _, check := range health.Checks {
+		check := check
+		if !check.Healthy {
+			unhealthy++
+		}
+		table.Rows = append(table.Rows, printer.Row{
+			Name:   check.Kind + "/" + check.Name,
+			Cells:  []string{check.Kind, check.Name, check.Status, fmt.Sprint(check.Healthy), check.Message},
+			Object: &check,
+		})
+	}
+	err = p.Print(table)
+	if err != nil {
+		return err
+	}
+
+	if !health.Healthy {
+		return fmt.Errorf("environment %s is unhealthy, %d check(s) failed", c.Name, unhealthy)
+	}
+	logger.Infof("Environment %s is healthy.", c.Name)
+	return nil
+}
@@ -11,11 +11,24 @@ type upgradeCmd struct {
 	Name    string `arg:"" required:"" help:"Environment name where engine will be upgraded."`
 	Engine  string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
 	Context string `optional:"" short:"c" help:"Kubernetes context where Environment will be upgraded."`
+	To      string `optional:"" help:"Version of Crossplane engine chart to upgrade to, version pinned by kndp by default, newer installed version is kept."`
+	Force   bool   `optional:"" help:"Allow downgrade of Crossplane engine."`
+	Ingress string `optional:"" help:"Ingress controller installed to environment: nginx, traefik or none."`
+	Policy  string `optional:"" help:"Policy controller installed to environment: kyverno or none."`
+
+	Values []string          `optional:"" help:"Engine Helm values file, could be repeated, later files take precedence."`
+	Set    map[string]string `optional:"" mapsep:"none" help:"Engine Helm value in format <key>=<value>, could be repeated, takes precedence over values files."`
 }
 
 func (c *upgradeCmd) Run(ctx context.Context, logger *zap.SugaredLogger) error {
 	return environment.
 		New(c.Engine, c.Name).
 		WithContext(c.Context).
+		WithEngineVersion(c.To).
+		WithForce(c.Force).
+		WithValueFiles(c.Values...).
+		WithOverrides(c.Set).
+		WithIngressController(c.Ingress).
+		WithPolicyController(c.Policy).
 		Upgrade(ctx, logger)
 }
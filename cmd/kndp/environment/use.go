@@ -0,0 +1,17 @@
+package environment
+
+import (
+	"github.com/kndpio/kndp/internal/environment"
+	"go.uber.org/zap"
+)
+
+type useCmd struct {
+	Name   string `arg:"" required:"" help:"Name of environment."`
+	Engine string `optional:"" help:"Specifies the Kubernetes engine to use for the runtime environment." default:"kind"`
+}
+
+func (c *useCmd) Run(logger *zap.SugaredLogger) error {
+	return environment.
+		New(c.Engine, c.Name).
+		Use(logger)
+}
@@ -0,0 +1,40 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/printer"
+)
+
+type versionsCmd struct {
+	printer.Output
+
+	Limit int `optional:"" short:"n" help:"Number of newest versions to list, all versions when 0." default:"10"`
+}
+
+func (c *versionsCmd) Run(ctx context.Context, p *printer.Printer) error {
+	versions, err := engine.AvailableVersions(ctx)
+	if err != nil {
+		return err
+	}
+	if c.Limit > 0 && len(versions) > c.Limit {
+		versions = versions[:c.Limit]
+	}
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "VERSION"},
+			{Header: "APP VERSION"},
+			{Header: "CREATED", Wide: true},
+		},
+	}
+	for _, v := range versions {
+		v := v
+		table.Rows = append(table.Rows, printer.Row{
+			Name:   v.Version,
+			Cells:  []string{v.Version, v.AppVersion, v.Created.Format("2006-01-02")},
+			Object: &v,
+		})
+	}
+	return p.Print(table)
+}
@@ -3,23 +3,41 @@ package provider
 import (
 	"context"
 
-	"github.com/pterm/pterm"
+	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"go.uber.org/zap"
 
+	"github.com/kndpio/kndp/internal/printer"
 	"github.com/kndpio/kndp/internal/provider"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 )
 
 type listCmd struct {
+	printer.Output
 }
 
-func (c *listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
+func (c *listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, p *printer.Printer, logger *zap.SugaredLogger) error {
 	providers := provider.ListProviders(ctx, dynamicClient, logger)
-	table := pterm.TableData{{"NAME", "PACKAGE"}}
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "PACKAGE"},
+			{Header: "INSTALLED", Wide: true},
+			{Header: "HEALTHY", Wide: true},
+		},
+	}
 	for _, provider := range providers {
-		table = append(table, []string{provider.Name, provider.Spec.Package})
+		provider := provider
+		table.Rows = append(table.Rows, printer.Row{
+			Name: provider.Name,
+			Cells: []string{
+				provider.Name,
+				provider.Spec.Package,
+				string(provider.GetCondition(crossv1.TypeInstalled).Status),
+				string(provider.GetCondition(crossv1.TypeHealthy).Status),
+			},
+			Object: &provider,
+		})
 	}
-	pterm.DefaultTable.WithHasHeader().WithData(table).Render()
-	return nil
+	return p.Print(table)
 }
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/printer"
+	"github.com/kndpio/kndp/internal/registry"
+	"go.uber.org/zap"
+
+	"k8s.io/client-go/kubernetes"
+	cfg "sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+type checkCmd struct {
+	printer.Output
+
+	Name       string `arg:"" optional:"" help:"Registry name, all registries are checked by default."`
+	Repository string `help:"Repository of which tags are listed, catalog of registry is listed by default."`
+	Context    string `short:"c" help:"Kubernetes context of registries."`
+}
+
+func (c *checkCmd) Run(ctx context.Context, client *kubernetes.Clientset, p *printer.Printer, logger *zap.SugaredLogger) error {
+	if c.Context != "" {
+		config, err := cfg.GetConfigWithContext(c.Context)
+		if err != nil {
+			return err
+		}
+		client, err = kube.Client(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	registries := []*registry.Registry{}
+	if c.Name != "" {
+		reg, err := registry.Get(ctx, client, c.Name)
+		if err != nil {
+			return err
+		}
+		registries = append(registries, reg)
+	} else {
+		var err error
+		registries, err = registry.Registries(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "SERVER"},
+			{Header: "STATUS"},
+			{Header: "MESSAGE"},
+		},
+	}
+	failed := 0
+	for _, reg := range registries {
+		for _, result := range reg.Check(ctx, c.Repository) {
+			result := result
+			if result.Status != registry.CheckOK {
+				failed++
+			}
+			table.Rows = append(table.Rows, printer.Row{
+				Name:   result.Registry,
+				Cells:  []string{result.Registry, result.Server, result.Status, result.Message},
+				Object: &result,
+			})
+		}
+	}
+	if err := p.Print(table); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d registry checks failed", failed)
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package registry
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/kndpio/kndp/internal/environment"
+	"github.com/kndpio/kndp/internal/kube"
 	"github.com/kndpio/kndp/internal/registry"
 	"go.uber.org/zap"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	cfg "sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 const (
@@ -15,24 +19,48 @@ const (
 )
 
 type createCmd struct {
-	RegistryServer string `help:"is your Private Registry FQDN."`
-	Username       string `help:"is your Username."`
-	Password       string `help:"is your Password."`
-	Email          string `help:"is your Email."`
-	Default        bool   `help:"Set registry as default."`
-	Local          bool   `help:"Create local registry."`
-	Context        string `short:"c" help:"Kubernetes context where registry will be created."`
+	RegistryServer []string `help:"is your Private Registry FQDN, could be repeated for servers with the same credentials, first one is primary."`
+	Username       string   `help:"is your Username."`
+	Password       string   `help:"is your Password."`
+	Token          string   `help:"is your access token, used instead of password, username is optional."`
+	Email          string   `help:"is your Email."`
+	DockerConfig   bool     `help:"Read credentials of servers from docker config and credential helpers."`
+	Verify         bool     `help:"Check credentials against registry servers before registry is created."`
+	Default        bool     `help:"Set registry as default."`
+	Local          bool     `help:"Create local registry."`
+	Storage        string   `help:"Storage of local registry: emptydir, pvc or host, which is /storage mount of environment."`
+	StorageSize    string   `help:"Size of persistent volume claim of local registry, by default 5Gi."`
+	TLS            bool     `name:"tls" help:"Serve local registry with self-signed certificate trusted by engine."`
+	Context        string   `short:"c" help:"Kubernetes context where registry will be created."`
 }
 
 func (c *createCmd) Run(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, logger *zap.SugaredLogger) error {
-	reg := registry.New(c.RegistryServer, c.Username, c.Password, c.Email)
+	reg, err := c.registry()
+	if err != nil {
+		return err
+	}
 	reg.SetDefault(c.Default)
 	reg.SetLocal(c.Local)
+	if !c.Local && (c.Storage != "" || c.StorageSize != "" || c.TLS) {
+		return fmt.Errorf("storage and tls flags require local registry")
+	}
+	if c.Local && (c.TLS || c.Username != "") {
+		err = c.checkNodesMirror(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
 	reg.WithContext(c.Context)
-	err := reg.Validate(ctx, client, logger)
+	err = reg.Validate(ctx, client, logger)
 	if err != nil {
 		return err
 	}
+	if c.Verify && !c.Local {
+		err = reg.Verify(ctx)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = reg.Create(ctx, config, logger)
 	if err != nil {
@@ -42,3 +70,57 @@ func (c *createCmd) Run(ctx context.Context, client *kubernetes.Clientset, confi
 	}
 	return nil
 }
+
+// Registry with credentials from flags or docker config
+func (c *createCmd) registry() (registry.Registry, error) {
+	switch {
+	case c.Local:
+		if c.Token != "" || c.DockerConfig || len(c.RegistryServer) > 0 {
+			return registry.Registry{}, fmt.Errorf("local registry supports only username and password auth")
+		}
+		reg := registry.NewLocal()
+		reg.WithLocalOptions(registry.LocalOptions{
+			Storage:     c.Storage,
+			StorageSize: c.StorageSize,
+			TLS:         c.TLS,
+			Username:    c.Username,
+			Password:    c.Password,
+		})
+		return reg, nil
+	case c.DockerConfig:
+		if c.Username != "" || c.Password != "" || c.Token != "" {
+			return registry.Registry{}, fmt.Errorf("credentials flags can't be used with docker config")
+		}
+		return registry.NewFromDockerConfig(c.RegistryServer)
+	case c.Token != "":
+		if c.Password != "" {
+			return registry.Registry{}, fmt.Errorf("password and token can't be used together")
+		}
+		return registry.New(c.RegistryServer, registry.TokenAuth(c.Username, c.Token)), nil
+	default:
+		return registry.New(c.RegistryServer, registry.NewAuth(c.Username, c.Password, c.Email)), nil
+	}
+}
+
+// Nodes of k3d environment mirror local registry by plain http without credentials,
+// which are configured on environment create only, by local registry of environment spec.
+func (c *createCmd) checkNodesMirror(ctx context.Context, client *kubernetes.Clientset) error {
+	if c.Context != "" {
+		config, err := cfg.GetConfigWithContext(c.Context)
+		if err != nil {
+			return err
+		}
+		client, err = kube.Client(config)
+		if err != nil {
+			return err
+		}
+	}
+	engine, err := environment.ClusterEngine(ctx, client)
+	if err != nil {
+		return err
+	}
+	if engine == "k3d" {
+		return fmt.Errorf("tls and auth of local registry are not supported for existing k3d environment, nodes couldn't pull from it; request local registry in environment spec instead")
+	}
+	return nil
+}
@@ -3,33 +3,48 @@ package registry
 import (
 	"context"
 
+	"github.com/kndpio/kndp/internal/printer"
 	"github.com/kndpio/kndp/internal/registry"
-	"github.com/pterm/pterm"
 	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
 )
 
 type listCmd struct {
+	printer.Output
 }
 
-func (c listCmd) Run(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+func (c listCmd) Run(ctx context.Context, client *kubernetes.Clientset, p *printer.Printer, logger *zap.SugaredLogger) error {
 	registries, err := registry.Registries(ctx, client)
 	if err != nil {
 		logger.Error(err)
 	}
 
-	tableRegs := pterm.TableData{
-		{"NAME", "SERVER", "DATE"},
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "SERVER"},
+			{Header: "DATE"},
+			{Header: "TYPE", Wide: true},
+		},
 	}
-
 	for _, reg := range registries {
-		tableRegs = append(tableRegs, []string{
-			reg.Name,
-			reg.Annotations["kndp-registry-server-url"],
-			reg.CreationTimestamp.String(),
+		server := reg.Annotations[registry.RegistryServerLabel]
+		table.Rows = append(table.Rows, printer.Row{
+			Name: reg.Name,
+			Cells: []string{
+				reg.Name,
+				server,
+				reg.CreationTimestamp.String(),
+				string(reg.Type),
+			},
+			// Credentials of registry are never printed
+			Object: map[string]any{
+				"name":              reg.Name,
+				"server":            server,
+				"creationTimestamp": reg.CreationTimestamp,
+				"type":              reg.Type,
+			},
 		})
 	}
-	pterm.DefaultTable.WithHasHeader().WithData(tableRegs).Render()
-
-	return nil
+	return p.Print(table)
 }
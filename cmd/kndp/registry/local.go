@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/kndpio/kndp/internal/printer"
+	"github.com/kndpio/kndp/internal/registry"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/rest"
+	cfg "sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+type localCmd struct {
+	List   localListCmd   `cmd:"" name:"ls" aliases:"list" help:"List images of local registry"`
+	Remove localRemoveCmd `cmd:"" name:"rm" help:"Remove image from local registry"`
+	Gc     localGcCmd     `cmd:"" help:"Remove old images of local registry, which are not used by packages"`
+}
+
+type localListCmd struct {
+	printer.Output
+
+	Repository string `arg:"" optional:"" help:"Repository of images, all repositories are listed by default."`
+	Context    string `short:"c" help:"Kubernetes context of local registry."`
+}
+
+func (c *localListCmd) Run(ctx context.Context, config *rest.Config, p *printer.Printer, logger *zap.SugaredLogger) error {
+	config, err := contextConfig(config, c.Context)
+	if err != nil {
+		return err
+	}
+	images, err := registry.ListLocalImages(ctx, config, c.Repository, logger)
+	if err != nil {
+		return err
+	}
+	return p.Print(imageTable(images))
+}
+
+type localRemoveCmd struct {
+	Reference string `arg:"" help:"Image reference as repository:tag or repository@digest, all tags of image are removed."`
+	Force     bool   `help:"Remove image even if it is used by installed packages."`
+	Context   string `short:"c" help:"Kubernetes context of local registry."`
+}
+
+func (c *localRemoveCmd) Run(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
+	config, err := contextConfig(config, c.Context)
+	if err != nil {
+		return err
+	}
+	images, err := registry.DeleteLocalImage(ctx, config, c.Reference, c.Force, logger)
+	if err != nil {
+		return err
+	}
+	logger.Infof("%d images removed from local registry.", len(images))
+	return nil
+}
+
+type localGcCmd struct {
+	printer.Output
+
+	Repository string `arg:"" optional:"" help:"Repository of images, all repositories are collected by default."`
+	Keep       int    `help:"Number of newest images kept in each repository." default:"3"`
+	DryRun     bool   `help:"Only list images which would be removed."`
+	Confirm    bool   `help:"Confirm removal of images." default:"false"`
+	Context    string `short:"c" help:"Kubernetes context of local registry."`
+}
+
+func (c *localGcCmd) Run(ctx context.Context, config *rest.Config, p *printer.Printer, logger *zap.SugaredLogger) error {
+	config, err := contextConfig(config, c.Context)
+	if err != nil {
+		return err
+	}
+	garbage, err := registry.LocalGarbage(ctx, config, c.Repository, c.Keep, logger)
+	if err != nil {
+		return err
+	}
+	if len(garbage) == 0 {
+		logger.Info("No old images found in local registry.")
+		return nil
+	}
+	if err := p.Print(imageTable(garbage)); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+	return registry.CollectLocalGarbage(ctx, config, garbage, c.Confirm, logger)
+}
+
+// Config of requested context, current config by default
+func contextConfig(config *rest.Config, context string) (*rest.Config, error) {
+	if context == "" {
+		return config, nil
+	}
+	return cfg.GetConfigWithContext(context)
+}
+
+func imageTable(images []registry.LocalImage) printer.Table {
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "REPOSITORY"},
+			{Header: "TAG"},
+			{Header: "SIZE"},
+			{Header: "AGE"},
+			{Header: "IN USE"},
+			{Header: "DIGEST", Wide: true},
+		},
+	}
+	for _, image := range images {
+		image := image
+		age := "<unknown>"
+		if !image.Created.IsZero() {
+			age = duration.HumanDuration(time.Since(image.Created))
+		}
+		table.Rows = append(table.Rows, printer.Row{
+			Name: image.Reference(),
+			Cells: []string{
+				image.Repository,
+				image.Tag,
+				units.HumanSize(float64(image.Size)),
+				age,
+				strconv.FormatBool(image.InUse),
+				image.Digest,
+			},
+			Object: &image,
+		})
+	}
+	return table
+}
@@ -4,4 +4,7 @@ type Cmd struct {
 	Create createCmd `cmd:"" help:"Create registry"`
 	List   listCmd   `cmd:"" help:"List registries"`
 	Delete deleteCmd `cmd:"" help:"Delete registry"`
+	Update updateCmd `cmd:"" help:"Update credentials of registry"`
+	Check  checkCmd  `cmd:"" help:"Check connectivity and credentials of registries"`
+	Local  localCmd  `cmd:"" help:"Browse and clean up images of local registry"`
 }
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/registry"
+	"go.uber.org/zap"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	cfg "sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+type updateCmd struct {
+	Name           string   `arg:"" required:"" help:"Registry name."`
+	RegistryServer []string `help:"Registry server to update, all servers of registry are updated by default."`
+	Username       string   `help:"is your Username, current username is kept by default."`
+	Password       string   `help:"is your Password, avoid it in favour of --password-stdin or --password-env."`
+	PasswordStdin  bool     `help:"Read password or token from stdin."`
+	PasswordEnv    string   `help:"Read password or token from environment variable."`
+	Email          string   `help:"is your Email, current email is kept by default."`
+	DockerConfig   bool     `help:"Read credentials of servers from docker config and credential helpers."`
+	Context        string   `short:"c" help:"Kubernetes context where registry will be updated."`
+}
+
+func (c *updateCmd) Run(ctx context.Context, client *kubernetes.Clientset, config *rest.Config, logger *zap.SugaredLogger) error {
+	if c.Context != "" {
+		var err error
+		config, err = cfg.GetConfigWithContext(c.Context)
+		if err != nil {
+			return err
+		}
+		client, err = kube.Client(config)
+		if err != nil {
+			return err
+		}
+	}
+	reg, err := registry.Get(ctx, client, c.Name)
+	if err != nil {
+		return err
+	}
+
+	if c.DockerConfig {
+		if c.Username != "" || c.Password != "" || c.PasswordStdin || c.PasswordEnv != "" {
+			return fmt.Errorf("credentials flags can't be used with docker config")
+		}
+		err = reg.SetDockerConfigCredentials(c.RegistryServer)
+		if err != nil {
+			return err
+		}
+	} else {
+		password, err := c.password(os.Stdin)
+		if err != nil {
+			return err
+		}
+		err = reg.SetCredentials(c.RegistryServer, c.Username, password, c.Email)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = reg.Update(ctx, config, logger)
+	if err != nil {
+		return err
+	}
+	logger.Info("Registry updated successfully.")
+	return nil
+}
+
+// Password from exactly one of flag, stdin or environment variable
+func (c *updateCmd) password(stdin io.Reader) (string, error) {
+	sources := 0
+	for _, set := range []bool{c.Password != "", c.PasswordStdin, c.PasswordEnv != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return "", fmt.Errorf("exactly one of --password, --password-stdin or --password-env is required")
+	}
+	switch {
+	case c.PasswordStdin:
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", fmt.Errorf("password is not provided on stdin")
+		}
+		return password, nil
+	case c.PasswordEnv != "":
+		password := os.Getenv(c.PasswordEnv)
+		if password == "" {
+			return "", fmt.Errorf("environment variable %s is not set", c.PasswordEnv)
+		}
+		return password, nil
+	default:
+		return c.Password, nil
+	}
+}
@@ -3,37 +3,66 @@ package resource
 import (
 	"context"
 
-	"github.com/ghodss/yaml"
+	"github.com/kndpio/kndp/internal/printer"
 	"github.com/kndpio/kndp/internal/resources"
-	"github.com/rodaine/table"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 )
 
 type listCmd struct {
+	printer.Output
 }
 
-func (listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, logger *zap.SugaredLogger) error {
-	tbl := table.New("NAME", "API-VERSION", "KIND", "CREATION-DATE", "UPDATE-DATE")
+func (listCmd) Run(ctx context.Context, config *rest.Config, dynamicClient *dynamic.DynamicClient, p *printer.Printer, logger *zap.SugaredLogger) error {
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "NAME"},
+			{Header: "API-VERSION"},
+			{Header: "KIND"},
+			{Header: "CREATION-DATE"},
+			{Header: "UPDATE-DATE"},
+			{Header: "SYNCED", Wide: true},
+			{Header: "READY", Wide: true},
+		},
+	}
 
 	xresources := resources.GetXResources(ctx, dynamicClient, logger)
 	for _, resource := range xresources {
 		labels := resource.GetLabels()
-		tbl.AddRow(resource.GetName(), resource.GetAPIVersion(), resource.GetKind(), labels["creation-date"], labels["update-date"])
-
-		jsonFormat, _ := resource.MarshalJSON()
-		yamlFormat, _ := yaml.JSONToYAML(jsonFormat)
-		logger.Infof("\n%s JSON: \n%s\n", resource.GetName(), string(jsonFormat))
-		logger.Infof("%s YAML: \n%s\n", resource.GetName(), string(yamlFormat))
+		table.Rows = append(table.Rows, printer.Row{
+			Name: resource.GetName(),
+			Cells: []string{
+				resource.GetName(),
+				resource.GetAPIVersion(),
+				resource.GetKind(),
+				labels["creation-date"],
+				labels["update-date"],
+				conditionStatus(resource, "Synced"),
+				conditionStatus(resource, "Ready"),
+			},
+			Object: resource.Object,
+		})
 	}
 
-	if len(xresources) > 0 {
-		tbl.Print()
-	} else {
+	if len(xresources) == 0 && !p.Structured() {
 		logger.Info("No resources found managed by kndp.")
+		return nil
 	}
+	return p.Print(table)
+}
 
-	return nil
+// Status of resource condition by type
+func conditionStatus(resource unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if ok && condition["type"] == conditionType {
+			status, _ := condition["status"].(string)
+			return status
+		}
+	}
+	return ""
 }
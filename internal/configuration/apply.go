@@ -26,16 +26,15 @@ func HealthCheck(ctx context.Context, dc dynamic.Interface, links string, wait b
 	for _, link := range strings.Split(links, ",") {
 		linkSet[link] = struct{}{}
 	}
-	cfgs := GetConfigurations(ctx, dc)
 
 	for {
 		select {
 		case <-timeoutChan:
 			logger.Error("Timeout reached.")
-			return nil
+			return errors.New("timeout waiting for configuration(s) to become healthy")
 		default:
 			allHealthy := true
-			for _, cfg := range cfgs {
+			for _, cfg := range GetConfigurations(ctx, dc) {
 				if _, linkMatched := linkSet[cfg.Spec.Package]; linkMatched {
 					if !CheckHealthStatus(cfg.Status.Conditions) {
 						allHealthy = false
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	v1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -29,12 +30,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type SecretReconciler struct {
 	serverIP string
+	setup    ProviderSetup
+	logger   *zap.SugaredLogger
+	done     bool
 	client.Client
 	context.CancelFunc
 }
@@ -46,10 +51,15 @@ const (
 	Version                = "1.15.2"
 	kindClusterRole        = "ClusterRole"
 	ProviderConfigName     = "kndp-kubernetes-provider-config"
-	helmProviderConfigName = "kndp-helm-provider-config"
+	HelmProviderConfigName = "kndp-helm-provider-config"
+	EnvironmentObjectName  = "environment"
 	aggregateToAdmin       = "rbac.crossplane.io/aggregate-to-admin"
 	trueVal                = "true"
 	errParsePackageName    = "package name is not valid"
+	reconcileInterval      = 2 * time.Second
+
+	KubernetesProviderPackage = "xpkg.upbound.io/crossplane-contrib/provider-kubernetes:v0.13.0"
+	HelmProviderPackage       = "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.18.1"
 )
 
 var (
@@ -78,6 +88,7 @@ func GetEngine(configClient *rest.Config) (install.Manager, error) {
 	setUpInstall := helm.InstallerModifierFn(helm.WithUpgradeInstall(true))
 	setCreateNs := helm.InstallerModifierFn(helm.WithCreateNamespace(true))
 	setReuseValues := helm.InstallerModifierFn(helm.WithReuseValues(true))
+	setRollbackOnError := helm.InstallerModifierFn(helm.RollbackOnError(true))
 
 	installer, err := helm.NewManager(
 		configClient,
@@ -89,6 +100,7 @@ func GetEngine(configClient *rest.Config) (install.Manager, error) {
 		setUpInstall,
 		setCreateNs,
 		setReuseValues,
+		setRollbackOnError,
 	)
 
 	if err != nil {
@@ -98,8 +110,8 @@ func GetEngine(configClient *rest.Config) (install.Manager, error) {
 	return installer, nil
 }
 
-// Install engine Helm release
-func InstallEngine(ctx context.Context, configClient *rest.Config, params map[string]any, logger *zap.SugaredLogger) error {
+// Install engine Helm release, version pinned by kndp is installed when version is not specified
+func InstallEngine(ctx context.Context, configClient *rest.Config, version string, params map[string]any, logger *zap.SugaredLogger) error {
 	engine, err := GetEngine(configClient)
 	if err != nil {
 		return err
@@ -108,8 +120,11 @@ func InstallEngine(ctx context.Context, configClient *rest.Config, params map[st
 	if params == nil {
 		params = initParameters
 	}
-	logger.Debug("Upgrade Crossplane release")
-	return engine.Upgrade(Version, params)
+	if version == "" {
+		version = Version
+	}
+	logger.Debugf("Upgrade Crossplane release to version %s", version)
+	return engine.Upgrade(version, params)
 }
 
 // Check if engine release exists
@@ -148,8 +163,29 @@ func ManagedSelector(m map[string]string) string {
 	return strings.Join(selectors, ",")
 }
 
+// Managed labels as unstructured object field
+func managedLabelsObject() map[string]interface{} {
+	labels := map[string]interface{}{}
+	for k, v := range ManagedLabels(nil) {
+		labels[k] = v
+	}
+	return labels
+}
+
+// Options of privileged Kubernetes provider setup
+type ProviderSetup struct {
+	// Create ProviderConfig of provider-kubernetes
+	Kubernetes bool
+	// Create ProviderConfig of provider-helm
+	Helm bool
+	// Grant all permissions instead of Crossplane admin aggregated role
+	ClusterAdmin bool
+	// Maximum duration of setup, until ProviderConfigs are created
+	Timeout time.Duration
+}
+
 // Setup Kubernetes provider which has crossplane admin aggregation role assigned
-func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.Config, logger *zap.SugaredLogger) error {
+func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.Config, setup ProviderSetup, logger *zap.SugaredLogger) error {
 
 	pcn := ProviderConfigName
 
@@ -157,6 +193,7 @@ func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.C
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pcn,
 			Namespace: namespace.Namespace,
+			Labels:    ManagedLabels(nil),
 		},
 	}
 
@@ -164,6 +201,7 @@ func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.C
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pcn,
 			Namespace: namespace.Namespace,
+			Labels:    ManagedLabels(nil),
 			Annotations: map[string]string{
 				"kubernetes.io/service-account.name": sa.Name,
 			},
@@ -173,20 +211,39 @@ func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.C
 
 	cr := &rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: pcn,
+			Name:   pcn,
+			Labels: ManagedLabels(nil),
 		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"*", ""},
-				Verbs:     []string{"*"},
-				Resources: []string{"*"},
+	}
+	mutateRole := func() error {
+		cr.SetLabels(ManagedLabels(cr.GetLabels()))
+		if setup.ClusterAdmin {
+			cr.AggregationRule = nil
+			cr.Rules = []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"*", ""},
+					Verbs:     []string{"*"},
+					Resources: []string{"*"},
+				},
+			}
+			return nil
+		}
+		// Rules are aggregated by controller from roles of Crossplane and its packages
+		if cr.AggregationRule == nil {
+			cr.Rules = nil
+		}
+		cr.AggregationRule = &rbacv1.AggregationRule{
+			ClusterRoleSelectors: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{aggregateToAdmin: trueVal}},
 			},
-		},
+		}
+		return nil
 	}
 
 	crb := &rbacv1.ClusterRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: pcn,
+			Name:   pcn,
+			Labels: ManagedLabels(nil),
 		},
 		Subjects: []rbacv1.Subject{
 			{
@@ -206,27 +263,45 @@ func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.C
 	rbacv1.AddToScheme(scheme)
 	corev1.AddToScheme(scheme)
 	extv1.AddToScheme(scheme)
-	ctrl, _ := client.New(configClient, client.Options{Scheme: scheme})
+	ctrl, err := client.New(configClient, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
 	for _, res := range []client.Object{sa, saSec, cr, crb} {
-		_, err := controllerutil.CreateOrUpdate(ctx, ctrl, res, func() error {
+		mutate := func() error {
+			res.SetLabels(ManagedLabels(res.GetLabels()))
 			return nil
-		})
+		}
+		if res == cr {
+			mutate = mutateRole
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, ctrl, res, mutate)
 		if err != nil {
 			return err
 		}
 	}
 
 	svc := &corev1.Service{}
-	err := ctrl.Get(ctx, types.NamespacedName{Namespace: "default", Name: "kubernetes"}, svc)
+	err = ctrl.Get(ctx, types.NamespacedName{Namespace: "default", Name: "kubernetes"}, svc)
 	if err != nil {
 		return err
 	}
 
-	mgr, err := manager.New(configClient, manager.Options{})
+	mgr, err := manager.New(configClient, manager.Options{
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
 	if err != nil {
 		return err
 	}
-	mgrContext, cancel := context.WithCancel(context.Background())
+	mgrContext, cancel := context.WithTimeout(ctx, setup.Timeout)
+	defer cancel()
+	reconciler := &SecretReconciler{
+		Client:     ctrl,
+		CancelFunc: cancel,
+		serverIP:   "https://" + svc.Spec.ClusterIP + ":443",
+		setup:      setup,
+		logger:     logger,
+	}
 	if err = builder.
 		ControllerManagedBy(mgr).
 		For(&corev1.ServiceAccount{}).
@@ -245,15 +320,17 @@ func SetupPrivilegedKubernetesProvider(ctx context.Context, configClient *rest.C
 			},
 		},
 		).
-		Complete(&SecretReconciler{
-			Client:     ctrl,
-			CancelFunc: cancel,
-			serverIP:   "https://" + svc.Spec.ClusterIP + ":443",
-		}); err != nil {
+		Complete(reconciler); err != nil {
 		return err
 	}
 	logger.Debug("Starting reconciliation of Kubernetes Provider")
-	mgr.Start(mgrContext)
+	err = mgr.Start(mgrContext)
+	if err != nil {
+		return err
+	}
+	if !reconciler.done {
+		return fmt.Errorf("provider configs are not ready after %s", setup.Timeout)
+	}
 	return nil
 }
 
@@ -265,6 +342,9 @@ func (a *SecretReconciler) Reconcile(ctx context.Context, req reconcile.Request)
 		return reconcile.Result{}, err
 	} else if sec.GetName() != ProviderConfigName {
 		return reconcile.Result{Requeue: true}, nil
+	} else if len(sec.Data["token"]) == 0 {
+		// Token is populated asynchronously by token controller
+		return reconcile.Result{RequeueAfter: reconcileInterval}, nil
 	}
 
 	if _, err = controllerutil.CreateOrUpdate(ctx, a.Client, sec, func() error {
@@ -308,30 +388,54 @@ func (a *SecretReconciler) Reconcile(ctx context.Context, req reconcile.Request)
 		return reconcile.Result{}, err
 	}
 
-	crd := &extv1.CustomResourceDefinition{}
-	err = a.Get(ctx, types.NamespacedName{Name: "providerconfigs.kubernetes.crossplane.io"}, crd)
-	if err != nil {
-		return reconcile.Result{Requeue: true}, err
-	}
-
-	pc := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "kubernetes.crossplane.io/v1alpha1",
-			"kind":       "ProviderConfig",
-			"metadata": map[string]interface{}{
-				"name": ProviderConfigName,
+	credentials := map[string]interface{}{
+		"credentials": map[string]interface{}{
+			"secretRef": map[string]interface{}{
+				"key":       "kubeconfig",
+				"name":      ProviderConfigName,
+				"namespace": namespace.Namespace,
 			},
+			"source": "Secret",
 		},
 	}
 
-	hpc := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"apiVersion": "helm.crossplane.io/v1beta1",
-			"kind":       "ProviderConfig",
-			"metadata": map[string]interface{}{
-				"name": helmProviderConfigName,
+	providerConfigs := []struct {
+		enabled    bool
+		crd        string
+		apiVersion string
+		name       string
+	}{
+		{a.setup.Kubernetes, "providerconfigs.kubernetes.crossplane.io", "kubernetes.crossplane.io/v1alpha1", ProviderConfigName},
+		{a.setup.Helm, "providerconfigs.helm.crossplane.io", "helm.crossplane.io/v1beta1", HelmProviderConfigName},
+	}
+	for _, p := range providerConfigs {
+		if !p.enabled {
+			continue
+		}
+		crd := &extv1.CustomResourceDefinition{}
+		err = a.Get(ctx, types.NamespacedName{Name: p.crd}, crd)
+		if err != nil {
+			// CRD is installed by provider package asynchronously
+			a.logger.Debugf("Waiting for %s: %v", p.crd, err)
+			return reconcile.Result{RequeueAfter: reconcileInterval}, nil
+		}
+
+		pc := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": p.apiVersion,
+				"kind":       "ProviderConfig",
+				"metadata": map[string]interface{}{
+					"name":   p.name,
+					"labels": managedLabelsObject(),
+				},
 			},
-		},
+		}
+		if _, err = controllerutil.CreateOrUpdate(ctx, a.Client, pc, func() error {
+			pc.Object["spec"] = credentials
+			return nil
+		}); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
 
 	envObj := &unstructured.Unstructured{
@@ -339,61 +443,32 @@ func (a *SecretReconciler) Reconcile(ctx context.Context, req reconcile.Request)
 			"apiVersion": "kndp.io/v1alpha1",
 			"kind":       "Environment",
 			"metadata": map[string]interface{}{
-				"name": "environment",
+				"name":   EnvironmentObjectName,
+				"labels": managedLabelsObject(),
 			},
 			"spec": map[string]interface{}{
-				"crossplane:": map[string]interface{}{},
-				"kyverno:":    map[string]interface{}{},
-				"name":        ReleaseName,
-				"namespace":   namespace.Namespace,
+				"crossplane": map[string]interface{}{},
+				"kyverno":    map[string]interface{}{},
+				"name":       ReleaseName,
+				"namespace":  namespace.Namespace,
 				"configuration": map[string]interface{}{
 					"packages": []interface{}{},
 				},
 				"provider": map[string]interface{}{
 					"packages": []interface{}{},
 				},
-				"helmProviderCfgRef":       helmProviderConfigName,
+				"helmProviderCfgRef":       HelmProviderConfigName,
 				"kubernetesProviderCfgRef": ProviderConfigName,
 			},
 		},
 	}
 
-	if _, err = controllerutil.CreateOrUpdate(ctx, a.Client, pc, func() error {
-		pc.Object["spec"] = map[string]interface{}{
-			"credentials": map[string]interface{}{
-				"secretRef": map[string]interface{}{
-					"key":       "kubeconfig",
-					"name":      ProviderConfigName,
-					"namespace": namespace.Namespace,
-				},
-				"source": "Secret",
-			},
-		}
-		return nil
-	}); err != nil {
-		return reconcile.Result{}, err
-	}
-
-	if _, err = controllerutil.CreateOrUpdate(ctx, a.Client, hpc, func() error {
-		hpc.Object["spec"] = map[string]interface{}{
-			"credentials": map[string]interface{}{
-				"secretRef": map[string]interface{}{
-					"key":       "kubeconfig",
-					"name":      ProviderConfigName,
-					"namespace": namespace.Namespace,
-				},
-				"source": "Secret",
-			},
-		}
-		return nil
-	}); err != nil {
-		return reconcile.Result{}, err
-	}
-
+	// Environment object is optional, its definition is installed by kndp configuration
 	if _, err = controllerutil.CreateOrUpdate(ctx, a.Client, envObj, func() error { return nil }); err != nil {
-		return reconcile.Result{}, err
+		a.logger.Debugf("Environment object is not created: %v", err)
 	}
 
+	a.done = true
 	a.CancelFunc()
 
 	return reconcile.Result{}, nil
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/kndpio/kndp/internal/namespace"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	KubernetesProviderConfigResource = schema.GroupVersionResource{Group: "kubernetes.crossplane.io", Version: "v1alpha1", Resource: "providerconfigs"}
+	HelmProviderConfigResource       = schema.GroupVersionResource{Group: "helm.crossplane.io", Version: "v1beta1", Resource: "providerconfigs"}
+	environmentResource              = schema.GroupVersionResource{Group: "kndp.io", Version: "v1alpha1", Resource: "environments"}
+)
+
+// Remove provider configs, environment object and service account with RBAC
+// created by SetupPrivilegedKubernetesProvider, dependent resources are removed first.
+// Resources are selected by managed labels, so resources of other setups are kept.
+func RemovePrivilegedKubernetesProvider(ctx context.Context, configClient *rest.Config, logger *zap.SugaredLogger) error {
+	dynamicClient, err := dynamic.NewForConfig(configClient)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(configClient)
+	if err != nil {
+		return err
+	}
+	selector := metav1.ListOptions{LabelSelector: ManagedSelector(nil)}
+
+	for _, gvr := range []schema.GroupVersionResource{
+		environmentResource,
+		HelmProviderConfigResource,
+		KubernetesProviderConfigResource,
+	} {
+		list, err := dynamicClient.Resource(gvr).List(ctx, selector)
+		if err = ignoreNotFound(err); err != nil {
+			return err
+		}
+		if list == nil {
+			continue
+		}
+		for _, item := range list.Items {
+			err := dynamicClient.Resource(gvr).Delete(ctx, item.GetName(), metav1.DeleteOptions{})
+			if err = ignoreNotFound(err); err != nil {
+				return err
+			}
+			logger.Debugf("Removed %s %s", gvr.GroupResource().String(), item.GetName())
+		}
+	}
+
+	rbac := client.RbacV1()
+	core := client.CoreV1()
+	for _, remove := range []struct {
+		kind   string
+		list   func() ([]string, error)
+		delete func(name string) error
+	}{
+		{
+			"ClusterRoleBinding",
+			func() ([]string, error) {
+				list, err := rbac.ClusterRoleBindings().List(ctx, selector)
+				if err != nil {
+					return nil, err
+				}
+				names := []string{}
+				for _, item := range list.Items {
+					names = append(names, item.Name)
+				}
+				return names, nil
+			},
+			func(name string) error {
+				return rbac.ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		},
+		{
+			"ClusterRole",
+			func() ([]string, error) {
+				list, err := rbac.ClusterRoles().List(ctx, selector)
+				if err != nil {
+					return nil, err
+				}
+				names := []string{}
+				for _, item := range list.Items {
+					names = append(names, item.Name)
+				}
+				return names, nil
+			},
+			func(name string) error {
+				return rbac.ClusterRoles().Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		},
+		{
+			"Secret",
+			func() ([]string, error) {
+				list, err := core.Secrets(namespace.Namespace).List(ctx, selector)
+				if err != nil {
+					return nil, err
+				}
+				// Registry secrets are managed too, only service account tokens are removed
+				names := []string{}
+				for _, item := range list.Items {
+					if item.Type == corev1.SecretTypeServiceAccountToken {
+						names = append(names, item.Name)
+					}
+				}
+				return names, nil
+			},
+			func(name string) error {
+				return core.Secrets(namespace.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		},
+		{
+			"ServiceAccount",
+			func() ([]string, error) {
+				list, err := core.ServiceAccounts(namespace.Namespace).List(ctx, selector)
+				if err != nil {
+					return nil, err
+				}
+				names := []string{}
+				for _, item := range list.Items {
+					names = append(names, item.Name)
+				}
+				return names, nil
+			},
+			func(name string) error {
+				return core.ServiceAccounts(namespace.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+			},
+		},
+	} {
+		names, err := remove.list()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := ignoreNotFound(remove.delete(name)); err != nil {
+				return err
+			}
+			logger.Debugf("Removed %s %s", remove.kind, name)
+		}
+	}
+	return nil
+}
+
+// Uninstall engine Helm release
+func UninstallEngine(configClient *rest.Config, logger *zap.SugaredLogger) error {
+	if !IsHelmReleaseFound(configClient) {
+		logger.Warnf("Crossplane engine not found, in namespace %s", namespace.Namespace)
+		return nil
+	}
+	engine, err := GetEngine(configClient)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Uninstall Crossplane release")
+	return engine.Uninstall()
+}
+
+// Resource not found, as well as resource kind not served, is already removed
+func ignoreNotFound(err error) error {
+	if err == nil || kerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ghodss/yaml"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Chart version available in engine repository
+type ChartVersion struct {
+	Version    string    `json:"version"`
+	AppVersion string    `json:"appVersion"`
+	Created    time.Time `json:"created"`
+}
+
+type repoIndex struct {
+	Entries map[string][]ChartVersion `json:"entries"`
+}
+
+// Versions of engine chart available in repository, newest first
+func AvailableVersions(ctx context.Context) ([]ChartVersion, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(RepoUrl, "/")+"/index.yaml", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository index: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching repository index: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	index := repoIndex{}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error parsing repository index: %v", err)
+	}
+	versions := index.Entries[ChartName]
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, errI := semver.NewVersion(versions[i].Version)
+		vj, errJ := semver.NewVersion(versions[j].Version)
+		if errI != nil || errJ != nil {
+			return versions[i].Version > versions[j].Version
+		}
+		return vi.GreaterThan(vj)
+	})
+	return versions, nil
+}
+
+// Check if engine version is available in repository
+func VersionAvailable(ctx context.Context, version string) error {
+	versions, err := AvailableVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("engine version %s not found in %s", version, RepoUrl)
+}
+
+// Check change of engine version, downgrade is allowed only when forced
+func CheckVersionChange(current string, target string, force bool) error {
+	if current == "" || target == "" || force {
+		return nil
+	}
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return fmt.Errorf("error parsing installed engine version %s: %v", current, err)
+	}
+	targetVersion, err := semver.NewVersion(target)
+	if err != nil {
+		return fmt.Errorf("error parsing engine version %s: %v", target, err)
+	}
+	if targetVersion.LessThan(currentVersion) {
+		return fmt.Errorf("engine downgrade from %s to %s is not allowed, use force to downgrade", current, target)
+	}
+	return nil
+}
+
+// Version of release chart, default version for unknown release
+func ReleaseVersion(rel *release.Release) string {
+	if rel == nil || rel.Chart == nil || rel.Chart.Metadata == nil {
+		return Version
+	}
+	return rel.Chart.Metadata.Version
+}
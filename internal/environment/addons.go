@@ -0,0 +1,222 @@
+package environment
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/kndpio/kndp/internal/install"
+	"github.com/kndpio/kndp/internal/install/helm"
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	AddonNone      = "none"
+	IngressNginx   = "nginx"
+	IngressTraefik = "traefik"
+	PolicyKyverno  = "kyverno"
+
+	CheckIngress = "Ingress"
+	CheckPolicy  = "Policy"
+)
+
+// Controller installed to environment by Helm chart of tested version
+type addon struct {
+	kind      string
+	name      string
+	repoUrl   string
+	chart     string
+	version   string
+	release   string
+	namespace string
+	// Values of chart for environment
+	values func(e *Environment) map[string]any
+}
+
+var addons = []addon{
+	{
+		kind:      CheckIngress,
+		name:      IngressNginx,
+		repoUrl:   "https://kubernetes.github.io/ingress-nginx",
+		chart:     "ingress-nginx",
+		version:   "4.9.1",
+		release:   "kndp-ingress-nginx",
+		namespace: "ingress-nginx",
+		values:    nginxValues,
+	},
+	{
+		kind:      CheckIngress,
+		name:      IngressTraefik,
+		repoUrl:   "https://traefik.github.io/charts",
+		chart:     "traefik",
+		version:   "26.0.0",
+		release:   "kndp-traefik",
+		namespace: "traefik",
+		values:    traefikValues,
+	},
+	{
+		kind:      CheckPolicy,
+		name:      PolicyKyverno,
+		repoUrl:   "https://kyverno.github.io/kyverno",
+		chart:     "kyverno",
+		version:   "3.1.4",
+		release:   "kndp-kyverno",
+		namespace: "kyverno",
+		values: func(e *Environment) map[string]any {
+			return map[string]any{}
+		},
+	},
+}
+
+// Find addon of kind by name
+func findAddon(kind string, name string) (addon, error) {
+	names := []string{AddonNone}
+	for _, a := range addons {
+		if a.kind == kind {
+			if a.name == name {
+				return a, nil
+			}
+			names = append(names, a.name)
+		}
+	}
+	return addon{}, fmt.Errorf("%s controller '%s' not supported, available controllers: %v", kind, name, names)
+}
+
+// Validate ingress and policy controllers of options
+func (o EnvironmentOptions) Validate() error {
+	if o.ingressController != "" && o.ingressController != AddonNone {
+		if _, err := findAddon(CheckIngress, o.ingressController); err != nil {
+			return err
+		}
+	}
+	if o.policyController != "" && o.policyController != AddonNone {
+		if _, err := findAddon(CheckPolicy, o.policyController); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Install ingress and policy controllers requested by options, wait until they are ready
+func (e *Environment) installAddons(configClient *rest.Config, logger *zap.SugaredLogger) error {
+	for _, requested := range []struct{ kind, name string }{
+		{CheckIngress, e.options.ingressController},
+		{CheckPolicy, e.options.policyController},
+	} {
+		if requested.name == "" || requested.name == AddonNone {
+			continue
+		}
+		a, err := findAddon(requested.kind, requested.name)
+		if err != nil {
+			return err
+		}
+		installer, err := a.installer(configClient)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Installing %s controller %s, waiting for it to be ready", a.kind, a.name)
+		err = installer.Upgrade(a.version, a.values(e))
+		if err != nil {
+			return fmt.Errorf("failed to install %s controller %s: %v", a.kind, a.name, err)
+		}
+		logger.Infof("%s controller %s is ready.", a.kind, a.name)
+	}
+	return nil
+}
+
+// Helm manager of addon release, waits until resources are ready
+func (a addon) installer(configClient *rest.Config) (install.Manager, error) {
+	repoURL, err := url.Parse(a.repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	return helm.NewManager(
+		configClient,
+		a.chart,
+		repoURL,
+		a.release,
+		helm.InstallerModifierFn(helm.Wait()),
+		helm.InstallerModifierFn(helm.WithNamespace(a.namespace)),
+		helm.InstallerModifierFn(helm.WithUpgradeInstall(true)),
+		helm.InstallerModifierFn(helm.WithCreateNamespace(true)),
+		helm.InstallerModifierFn(helm.WithReuseValues(true)),
+	)
+}
+
+// Releases of installed addons
+func addonChecks(configClient *rest.Config) []Check {
+	checks := []Check{}
+	for _, a := range addons {
+		installer, err := a.installer(configClient)
+		if err != nil {
+			continue
+		}
+		rel, err := installer.GetRelease()
+		if err != nil {
+			continue
+		}
+		// Release without chart metadata is reported by version pinned for addon
+		version := a.version
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			version = rel.Chart.Metadata.Version
+		}
+		check := Check{
+			Kind:    a.kind,
+			Name:    a.name,
+			Status:  string(rel.Info.Status),
+			Healthy: rel.Info.Status == release.StatusDeployed,
+			Message: rel.Info.Description,
+			Details: map[string]any{
+				"release":   a.release,
+				"namespace": a.namespace,
+				"version":   version,
+			},
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// Ingress controller exposed by host ports of kind node labelled ingress-ready,
+// other engines expose it by load balancer service.
+func nginxValues(e *Environment) map[string]any {
+	if e.engine != "kind" {
+		return map[string]any{}
+	}
+	return map[string]any{
+		"controller": map[string]any{
+			"hostPort":       map[string]any{"enabled": true},
+			"service":        map[string]any{"type": "NodePort"},
+			"publishService": map[string]any{"enabled": false},
+			"extraArgs":      map[string]any{"publish-status-address": "localhost"},
+			"nodeSelector":   map[string]any{"ingress-ready": "true"},
+			"tolerations":    controlPlaneTolerations(),
+		},
+	}
+}
+
+func traefikValues(e *Environment) map[string]any {
+	if e.engine != "kind" {
+		return map[string]any{}
+	}
+	return map[string]any{
+		"ports": map[string]any{
+			"web":       map[string]any{"hostPort": 80},
+			"websecure": map[string]any{"hostPort": 443},
+		},
+		"service":      map[string]any{"type": "NodePort"},
+		"nodeSelector": map[string]any{"ingress-ready": "true"},
+		"tolerations":  controlPlaneTolerations(),
+	}
+}
+
+func controlPlaneTolerations() []any {
+	return []any{
+		map[string]any{
+			"key":      "node-role.kubernetes.io/control-plane",
+			"operator": "Exists",
+			"effect":   "NoSchedule",
+		},
+	}
+}
@@ -0,0 +1,384 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/kndpio/kndp/internal/configuration"
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/namespace"
+	"github.com/kndpio/kndp/internal/provider"
+	"github.com/kndpio/kndp/internal/registry"
+	"github.com/kndpio/kndp/internal/resources"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	CopyCreate   = "create"
+	CopyUpdate   = "update"
+	CopySkip     = "skip"
+	CopyConflict = "conflict"
+
+	KindComposite = "Composite"
+
+	copyHealthTimeout = 10 * time.Minute
+)
+
+// Plan of objects copied from source to destination context
+type CopyPlan struct {
+	Source      string     `json:"source"`
+	Destination string     `json:"destination"`
+	Items       []CopyItem `json:"items"`
+}
+
+// Object copied to destination with action and differences of existing object
+type CopyItem struct {
+	Kind    string      `json:"kind"`
+	Name    string      `json:"name"`
+	Action  string      `json:"action"`
+	Message string      `json:"message,omitempty"`
+	Diff    []FieldDiff `json:"diff,omitempty"`
+}
+
+// Difference of field value, empty value means that field is absent
+type FieldDiff struct {
+	Path        string `json:"path"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// Plan copy of Environment from source to destination contexts, nothing is changed in destination
+func (e *Environment) PlanCopy(ctx context.Context, source string, destination string) (*CopyPlan, error) {
+	if err := e.copyFilter.Validate(); err != nil {
+		return nil, err
+	}
+	sourceConfig, err := kube.Config(source)
+	if err != nil {
+		return nil, err
+	}
+	destConfig, err := kube.Config(destination)
+	if err != nil {
+		return nil, err
+	}
+	sourceClient, err := kube.Client(sourceConfig)
+	if err != nil {
+		return nil, err
+	}
+	destClient, err := kube.Client(destConfig)
+	if err != nil {
+		return nil, err
+	}
+	sourceDynamic, err := dynamic.NewForConfig(sourceConfig)
+	if err != nil {
+		return nil, err
+	}
+	destDynamic, err := dynamic.NewForConfig(destConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &CopyPlan{
+		Source:      source,
+		Destination: destination,
+	}
+
+	registries, err := planRegistries(ctx, sourceClient, destClient, e.copyAllows(CheckRegistry))
+	if err != nil {
+		return nil, err
+	}
+	plan.Items = append(plan.Items, registries...)
+
+	if e.copyFilter.Allows(CheckEngine, engine.ReleaseName) {
+		engineItem, err := planEngine(sourceConfig, destConfig)
+		if err != nil {
+			return nil, err
+		}
+		plan.Items = append(plan.Items, engineItem)
+	}
+
+	for _, kind := range []string{CheckProvider, CheckConfiguration} {
+		gvr := provider.ResourceId()
+		if kind == CheckConfiguration {
+			gvr = configuration.ResourceId()
+		}
+		packages, err := e.copiedPackages(ctx, sourceDynamic, gvr, kind)
+		if err != nil {
+			return nil, err
+		}
+		items, err := planPackages(ctx, destDynamic, gvr, kind, packages)
+		if err != nil {
+			return nil, err
+		}
+		plan.Items = append(plan.Items, items...)
+	}
+
+	composites, err := planComposites(ctx, sourceDynamic, destDynamic, e.copyAllows(KindComposite))
+	if err != nil {
+		return nil, err
+	}
+	plan.Items = append(plan.Items, composites...)
+
+	return plan, nil
+}
+
+// Check if any object of plan conflicts with existing one
+func (p *CopyPlan) HasConflicts() bool {
+	return len(p.Conflicts()) > 0
+}
+
+// Objects of plan, which conflict with existing ones
+func (p *CopyPlan) Conflicts() []CopyItem {
+	conflicts := []CopyItem{}
+	for _, item := range p.Items {
+		if item.Action == CopyConflict {
+			conflicts = append(conflicts, item)
+		}
+	}
+	return conflicts
+}
+
+// Registries with same server are skipped, other secrets with same name are conflicting
+func planRegistries(ctx context.Context, sourceClient *kubernetes.Clientset, destClient *kubernetes.Clientset, include func(name string) bool) ([]CopyItem, error) {
+	registries, err := registry.Registries(ctx, sourceClient)
+	if err != nil {
+		return nil, err
+	}
+	items := []CopyItem{}
+	for _, reg := range registries {
+		if !include(reg.Name) {
+			continue
+		}
+		item := CopyItem{Kind: CheckRegistry, Name: reg.Name, Action: CopyCreate}
+		if reg.Exists(ctx, destClient) {
+			item.Action = CopySkip
+			item.Message = "registry for " + reg.Annotations[registry.RegistryServerLabel] + " already exists"
+		} else {
+			_, err := destClient.CoreV1().Secrets(namespace.Namespace).Get(ctx, reg.Name, metav1.GetOptions{})
+			if err == nil {
+				item.Action = CopyConflict
+				item.Message = "secret " + reg.Name + " already exists and is not a registry for " + reg.Annotations[registry.RegistryServerLabel]
+			} else if !kerrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Engine release is upgraded in destination by values of source release
+func planEngine(sourceConfig *rest.Config, destConfig *rest.Config) (CopyItem, error) {
+	item := CopyItem{Kind: CheckEngine, Name: engine.ReleaseName, Action: CopyCreate}
+	sourceEngine, err := engine.GetEngine(sourceConfig)
+	if err != nil {
+		return item, err
+	}
+	sourceRelease, err := sourceEngine.GetRelease()
+	if err != nil {
+		return item, fmt.Errorf("engine release not found in source context: %v", err)
+	}
+	destEngine, err := engine.GetEngine(destConfig)
+	if err != nil {
+		return item, err
+	}
+	destRelease, err := destEngine.GetRelease()
+	if err != nil {
+		return item, nil
+	}
+	item.Diff = DiffValues(sourceRelease.Config, destRelease.Config)
+	if len(item.Diff) == 0 {
+		item.Action = CopySkip
+		item.Message = "values are equal"
+	} else {
+		item.Action = CopyUpdate
+		item.Message = fmt.Sprintf("%d value(s) differ", len(item.Diff))
+	}
+	return item, nil
+}
+
+// Composites existing in destination are skipped when equal and conflicting when spec differs
+func planComposites(ctx context.Context, sourceDynamic dynamic.Interface, destDynamic dynamic.Interface, include func(name string) bool) ([]CopyItem, error) {
+	composites, err := resources.ManagedComposites(ctx, sourceDynamic)
+	if err != nil {
+		return nil, err
+	}
+	items := []CopyItem{}
+	for _, composite := range composites {
+		if !include(composite.Object.GetName()) {
+			continue
+		}
+		gr := composite.ResourceId().GroupResource()
+		item := CopyItem{Kind: KindComposite, Name: gr.String() + "/" + composite.Object.GetName(), Action: CopyCreate}
+		existing, err := destDynamic.Resource(composite.ResourceId()).Get(ctx, composite.Object.GetName(), metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			items = append(items, item)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		item.Diff = DiffValues(compositeSpec(&composite.Object), compositeSpec(existing))
+		if len(item.Diff) == 0 {
+			item.Action = CopySkip
+			item.Message = "already exists"
+		} else {
+			item.Action = CopyConflict
+			item.Message = fmt.Sprintf("already exists, %d field(s) differ", len(item.Diff))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Packages existing in destination are skipped when package is same and conflicting otherwise
+func planPackages(ctx context.Context, destDynamic dynamic.Interface, gvr schema.GroupVersionResource, kind string, packages []unstructured.Unstructured) ([]CopyItem, error) {
+	items := []CopyItem{}
+	for _, pkg := range packages {
+		item := CopyItem{Kind: kind, Name: pkg.GetName(), Action: CopyCreate}
+		existing, err := destDynamic.Resource(gvr).Get(ctx, pkg.GetName(), metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			items = append(items, item)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		item.Diff = DiffValues(packageSpec(&pkg), packageSpec(existing))
+		if len(item.Diff) == 0 {
+			item.Action = CopySkip
+			item.Message = "already exists"
+		} else {
+			item.Action = CopyConflict
+			item.Message = fmt.Sprintf("already exists, %d field(s) differ", len(item.Diff))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Packages of kind from source, allowed by copy filter
+func (e *Environment) copiedPackages(ctx context.Context, sourceDynamic dynamic.Interface, gvr schema.GroupVersionResource, kind string) ([]unstructured.Unstructured, error) {
+	list, err := sourceDynamic.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	packages := []unstructured.Unstructured{}
+	for _, pkg := range list.Items {
+		if e.copyFilter.Allows(kind, pkg.GetName()) {
+			resources.CleanObject(&pkg)
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// Package references of package objects
+func packageLinks(packages []unstructured.Unstructured) []string {
+	links := []string{}
+	for _, pkg := range packages {
+		link, _, _ := unstructured.NestedString(pkg.Object, "spec", "package")
+		links = append(links, link)
+	}
+	return links
+}
+
+// Filter of object names for kind
+func (e *Environment) copyAllows(kind string) func(name string) bool {
+	return func(name string) bool {
+		return e.copyFilter.Allows(kind, name)
+	}
+}
+
+func packageSpec(u *unstructured.Unstructured) map[string]any {
+	spec, _, _ := unstructured.NestedMap(u.Object, "spec")
+	return map[string]any{"spec": spec}
+}
+
+// Spec of composite without references to resources composed in cluster
+func compositeSpec(u *unstructured.Unstructured) map[string]any {
+	spec, _, _ := unstructured.NestedMap(u.Object, "spec")
+	delete(spec, "resourceRefs")
+	return map[string]any{"spec": spec}
+}
+
+// Field level differences of values, paths are sorted
+func DiffValues(source map[string]any, destination map[string]any) []FieldDiff {
+	sourceFields := map[string]any{}
+	flattenValues("", source, sourceFields)
+	destFields := map[string]any{}
+	flattenValues("", destination, destFields)
+
+	paths := []string{}
+	for path := range sourceFields {
+		paths = append(paths, path)
+	}
+	for path := range destFields {
+		if _, ok := sourceFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	diffs := []FieldDiff{}
+	for _, path := range paths {
+		s, inSource := sourceFields[path]
+		d, inDest := destFields[path]
+		if inSource && inDest && reflect.DeepEqual(s, d) {
+			continue
+		}
+		diff := FieldDiff{Path: path}
+		if inSource {
+			diff.Source = formatValue(s)
+		}
+		if inDest {
+			diff.Destination = formatValue(d)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// Flatten nested maps to dot separated paths, lists are compared as whole values
+func flattenValues(prefix string, values map[string]any, fields map[string]any) {
+	for k, v := range values {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			flattenValues(path, m, fields)
+			continue
+		}
+		fields[path] = normalizeValue(v)
+	}
+}
+
+// Round trip value through JSON, so numbers and typed values are compared equally
+func normalizeValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,22 @@
+package environment
+
+import "testing"
+
+func TestCopyPlanConflicts(t *testing.T) {
+	plan := &CopyPlan{Items: []CopyItem{
+		{Kind: CheckRegistry, Name: "ghcr", Action: CopySkip},
+		{Kind: CheckEngine, Name: "crossplane", Action: CopyUpdate},
+		{Kind: KindComposite, Name: "databases.example.org/db", Action: CopyCreate},
+	}}
+	if plan.HasConflicts() {
+		t.Errorf("HasConflicts() = true, want false for %v", plan.Items)
+	}
+
+	plan.Items = append(plan.Items, CopyItem{Kind: CheckProvider, Name: "provider-aws", Action: CopyConflict})
+	if !plan.HasConflicts() {
+		t.Errorf("HasConflicts() = false, want true for %v", plan.Items)
+	}
+	if conflicts := plan.Conflicts(); len(conflicts) != 1 || conflicts[0].Name != "provider-aws" {
+		t.Errorf("Conflicts() = %v, want provider-aws", conflicts)
+	}
+}
@@ -0,0 +1,132 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	docker "github.com/docker/docker/client"
+	"go.uber.org/zap"
+)
+
+// Start containers of environment, selected by cluster label of engine
+func (e *Environment) startContainers(ctx context.Context, label string, logger *zap.SugaredLogger) error {
+	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv)
+	if err != nil {
+		return err
+	}
+	containers, err := e.containers(ctx, dockerClient, label)
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if c.State == "running" {
+			continue
+		}
+		logger.Debugf("Starting container %s", containerName(c))
+		err := dockerClient.ContainerStart(ctx, c.ID, types.ContainerStartOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to start container %s: %v", containerName(c), err)
+		}
+	}
+	return nil
+}
+
+// Stop containers of environment, selected by cluster label of engine
+func (e *Environment) stopContainers(ctx context.Context, label string, logger *zap.SugaredLogger) error {
+	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv)
+	if err != nil {
+		return err
+	}
+	containers, err := e.containers(ctx, dockerClient, label)
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		logger.Debugf("Stopping container %s", containerName(c))
+		err := dockerClient.ContainerStop(ctx, c.ID, container.StopOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to stop container %s: %v", containerName(c), err)
+		}
+	}
+	return nil
+}
+
+// Containers labeled by environment name, error if there are none
+func (e *Environment) containers(ctx context.Context, dockerClient *docker.Client, label string) ([]types.Container, error) {
+	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", label+"="+e.name)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("containers of environment %s not found", e.name)
+	}
+	return containers, nil
+}
+
+func containerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// Status of environment by state of containers with label
+func containersStatus(ctx context.Context, label string, value string) (EngineStatus, error) {
+	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv)
+	if err != nil {
+		return StatusUnknown, err
+	}
+	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", label+"="+value)),
+	})
+	if err != nil {
+		return StatusUnknown, err
+	}
+	if len(containers) == 0 {
+		return StatusNotFound, nil
+	}
+	for _, c := range containers {
+		if c.State != "running" {
+			return StatusStopped, nil
+		}
+	}
+	return StatusRunning, nil
+}
+
+// Names of clusters by values of cluster label on containers
+func labeledClusters(ctx context.Context, label string) ([]string, error) {
+	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", label)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	found := map[string]bool{}
+	for _, c := range containers {
+		name := c.Labels[label]
+		if name != "" && !found[name] {
+			found[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,83 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// Engine is a Kubernetes runtime which hosts Environment clusters
+type Engine interface {
+	// Create cluster of environment and return name of its Kubernetes context
+	Create(ctx context.Context, e *Environment, logger *zap.SugaredLogger) (string, error)
+	Delete(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error
+	Start(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error
+	Stop(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error
+	ContextName(e *Environment) string
+	Exists(ctx context.Context, e *Environment) (bool, error)
+	Status(ctx context.Context, e *Environment) (EngineStatus, error)
+}
+
+// Engine which lists clusters it hosts, clusters of container engines are found without their contexts
+type clusterLister interface {
+	Clusters(ctx context.Context) ([]string, error)
+}
+
+// State of environment cluster reported by engine
+type EngineStatus string
+
+const (
+	StatusRunning  EngineStatus = "running"
+	StatusStopped  EngineStatus = "stopped"
+	StatusNotFound EngineStatus = "not found"
+	StatusUnknown  EngineStatus = "unknown"
+)
+
+var engines = map[string]Engine{}
+
+// Register Kubernetes engine by name, registered engine replaces existing one
+func RegisterEngine(name string, engine Engine) {
+	engines[name] = engine
+}
+
+// Names of registered Kubernetes engines
+func Engines() []string {
+	names := []string{}
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Engine with prefix of context names, which are named by environment name after prefix
+type contextPrefix struct {
+	engine string
+	prefix string
+}
+
+// Context name prefixes of registered engines, longer prefixes are more specific and go first
+func contextPrefixes() []contextPrefix {
+	prefixes := []contextPrefix{}
+	for _, name := range Engines() {
+		prefixes = append(prefixes, contextPrefix{
+			engine: name,
+			prefix: engines[name].ContextName(New(name, "")),
+		})
+	}
+	sort.SliceStable(prefixes, func(i, j int) bool {
+		return len(prefixes[i].prefix) > len(prefixes[j].prefix)
+	})
+	return prefixes
+}
+
+// Kubernetes engine of environment
+func (e *Environment) kubernetesEngine() (Engine, error) {
+	engine, ok := engines[e.engine]
+	if !ok {
+		return nil, fmt.Errorf("Kubernetes engine '%s' not supported, available engines: %v", e.engine, Engines())
+	}
+	return engine, nil
+}
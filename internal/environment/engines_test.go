@@ -0,0 +1,94 @@
+package environment
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// Register fake engine by name for duration of test
+func registerFakeEngine(t *testing.T, name string) *FakeEngine {
+	t.Helper()
+	fake := NewFakeEngine()
+	previous, ok := engines[name]
+	RegisterEngine(name, fake)
+	t.Cleanup(func() {
+		if ok {
+			engines[name] = previous
+		} else {
+			delete(engines, name)
+		}
+	})
+	return fake
+}
+
+func TestRegisterEngine(t *testing.T) {
+	fake := registerFakeEngine(t, "fake")
+
+	names := Engines()
+	for _, name := range []string{"fake", "k3d", "k3s", "kind"} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("Engines() = %v, missing %s", names, name)
+		}
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("Engines() = %v, not sorted", names)
+		}
+	}
+
+	engine, err := New("fake", "dev").kubernetesEngine()
+	if err != nil {
+		t.Fatalf("kubernetesEngine() error = %v", err)
+	}
+	if engine != fake {
+		t.Errorf("kubernetesEngine() = %v, want registered fake engine", engine)
+	}
+
+	replaced := NewFakeEngine()
+	RegisterEngine("fake", replaced)
+	if engine, _ := New("fake", "dev").kubernetesEngine(); engine != replaced {
+		t.Errorf("kubernetesEngine() = %v, want replaced fake engine", engine)
+	}
+}
+
+func TestUnsupportedEngine(t *testing.T) {
+	if _, err := New("unknown", "dev").kubernetesEngine(); err == nil {
+		t.Error("kubernetesEngine() of unknown engine succeeded, want error")
+	}
+	if err := New("unknown", "dev").Start(context.Background(), false, nil); err == nil {
+		t.Error("Start() with unknown engine succeeded, want error")
+	}
+}
+
+func TestContextPrefixes(t *testing.T) {
+	registerFakeEngine(t, "fake")
+	RegisterEngine("fake", prefixEngine{FakeEngine: NewFakeEngine(), prefix: "fake-cluster-"})
+
+	prefixes := contextPrefixes()
+	got := map[string]string{}
+	for i, p := range prefixes {
+		got[p.engine] = p.prefix
+		if i > 0 && len(prefixes[i-1].prefix) < len(p.prefix) {
+			t.Errorf("contextPrefixes() = %v, longer prefixes must go first", prefixes)
+		}
+	}
+	want := map[string]string{"fake": "fake-cluster-", "k3d": "k3d-", "k3s": "", "kind": "kind-"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contextPrefixes() = %v, want %v", got, want)
+	}
+}
+
+// Fake engine with custom context name prefix
+type prefixEngine struct {
+	*FakeEngine
+	prefix string
+}
+
+func (p prefixEngine) ContextName(e *Environment) string {
+	return p.prefix + e.name
+}
@@ -5,33 +5,51 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	docker "github.com/docker/docker/client"
+	"github.com/kndpio/kndp/internal/configuration"
 	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/install/helm"
 	"github.com/kndpio/kndp/internal/kube"
 	"github.com/kndpio/kndp/internal/namespace"
+	"github.com/kndpio/kndp/internal/provider"
 	"github.com/kndpio/kndp/internal/registry"
 	"github.com/kndpio/kndp/internal/resources"
-	"github.com/pterm/pterm"
-	"k8s.io/client-go/tools/clientcmd"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
 	"go.uber.org/zap"
 )
 
+const (
+	apiServerReadyTimeout = 5 * time.Minute
+	apiServerPollInterval = 2 * time.Second
+)
+
 type Environment struct {
-	name      string
-	engine    string
-	httpPort  int
-	httpsPort int
-	mountPath string
-	context   string
-	options   EnvironmentOptions
+	name           string
+	engine         string
+	httpPort       int
+	httpsPort      int
+	mountPath      string
+	mounts         []Mount
+	topology       Topology
+	context        string
+	values         map[string]any
+	providers      []string
+	configurations []string
+	registries     []RegistrySpec
+	uninstall      bool
+	engineVersion  string
+	privileged     PrivilegedProvidersSpec
+	valueFiles     []string
+	overrides      map[string]string
+	force          bool
+	copyFilter     CopyFilter
+	ttl            time.Duration
+	options        EnvironmentOptions
 }
 
 // New Environment entity
@@ -44,34 +62,36 @@ func New(engine string, name string) *Environment {
 
 // Create environment
 func (e *Environment) Create(ctx context.Context, logger *zap.SugaredLogger) error {
-	var err error
+	if err := e.options.Validate(); err != nil {
+		return err
+	}
+	if e.ttl > 0 && e.context != "" {
+		return fmt.Errorf("ttl is supported only for environments created by Kubernetes engine")
+	}
+	if e.engineVersion != "" {
+		if err := engine.VersionAvailable(ctx, e.engineVersion); err != nil {
+			return err
+		}
+	}
 	if e.context == "" {
-		switch e.engine {
-		case "kind":
-			logger.Info("Creating environment with Kubernetes engine 'kind'")
-			e.context, err = e.CreateKindEnvironment(logger)
-			if err != nil {
-				return err
-			}
-		case "k3s":
-			logger.Info("Creating environment with Kubernetes engine 'k3s'")
-			e.context, err = e.CreateK3sEnvironment(logger)
-			if err != nil {
-				return err
-			}
-		case "k3d":
-			logger.Info("Creating environment with Kubernetes engine 'k3d'")
-			e.context, err = e.CreateK3dEnvironment(logger)
-			if err != nil {
-				return err
-			}
-		default:
-			logger.Errorf("Kubernetes engine '%s' not supported", e.engine)
-			return nil
+		engine, err := e.kubernetesEngine()
+		if err != nil {
+			return err
+		}
+		logger.Infof("Creating environment with Kubernetes engine '%s'", e.engine)
+		e.context, err = engine.Create(ctx, e, logger)
+		if err != nil {
+			return err
+		}
+		if err := e.recordState(); err != nil {
+			logger.Warnf("Failed to record environment state: %v", err)
+		}
+		if e.ttl > 0 {
+			logger.Infof("Environment expires in %s, it is deleted by garbage collection after expiry.", e.ttl)
 		}
 	}
 
-	err = e.Setup(ctx, logger)
+	err := e.Setup(ctx, logger)
 	if err != nil {
 		return err
 	}
@@ -81,13 +101,21 @@ func (e *Environment) Create(ctx context.Context, logger *zap.SugaredLogger) err
 
 // Upgrade environemnt with options or new features
 func (e *Environment) Upgrade(ctx context.Context, logger *zap.SugaredLogger) error {
+	if err := e.options.Validate(); err != nil {
+		return err
+	}
 	var err error
+	if e.engineVersion != "" {
+		if err := engine.VersionAvailable(ctx, e.engineVersion); err != nil {
+			return err
+		}
+	}
 	if e.context == "" {
-		e.context = e.GetContextName()
-		if e.context == "" {
-			logger.Fatalf("Kubernetes engine '%s' not supported", e.engine)
-			return nil
+		engine, err := e.kubernetesEngine()
+		if err != nil {
+			return err
 		}
+		e.context = engine.ContextName(e)
 	}
 
 	err = e.Setup(ctx, logger)
@@ -118,26 +146,74 @@ func confirmationPrompt(s string, logger *zap.SugaredLogger) bool {
 	}
 }
 
-// Delete environment cluster
-func (e *Environment) Delete(f bool, logger *zap.SugaredLogger) error {
-	var err error
+// Delete environment cluster, or engine with its resources from context of existing cluster
+func (e *Environment) Delete(ctx context.Context, f bool, logger *zap.SugaredLogger) error {
+	if e.context != "" {
+		if !f && !confirmationPrompt(fmt.Sprintf("Do you really want to remove environment from context %s ?", e.context), logger) {
+			return nil
+		}
+		return e.Teardown(ctx, logger)
+	}
+	engine, err := e.kubernetesEngine()
+	if err != nil {
+		return err
+	}
 	if !f && !confirmationPrompt(fmt.Sprintf("Do you really want to delete environment %s ?", e.name), logger) {
 		return nil
 	}
-	switch e.engine {
-	case "kind":
-		err = e.DeleteKindEnvironment(logger)
-	case "k3s":
-		err = e.DeleteK3sEnvironment(logger)
-	case "k3d":
-		err = e.DeleteK3dEnvironment(logger)
-	default:
-		logger.Fatalf("Kubernetes engine '%s' not supported", e.engine)
-		return nil
+	err = engine.Delete(ctx, e, logger)
+	if err != nil {
+		return err
+	}
+	if err := e.forgetState(); err != nil {
+		logger.Warnf("Failed to remove environment state: %v", err)
+	}
+	return nil
+}
+
+// Remove engine and resources managed by kndp from context, cluster is kept.
+// Provider configs and their credentials are removed before engine,
+// registries are removed after engine, which pulls packages from them.
+func (e *Environment) Teardown(ctx context.Context, logger *zap.SugaredLogger) error {
+	configClient, err := kube.Config(e.context)
+	if err != nil {
+		return err
+	}
+	client, err := kube.Client(configClient)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Removing provider configs and credentials")
+	err = engine.RemovePrivilegedKubernetesProvider(ctx, configClient, logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Uninstalling engine")
+	err = engine.UninstallEngine(configClient, logger)
+	if err != nil {
+		return err
 	}
+
+	logger.Info("Removing registries")
+	local, err := registry.LocalRegistryExists(ctx, client)
 	if err != nil {
 		return err
 	}
+	if local {
+		reg := registry.NewLocal()
+		err = reg.DeleteLocal(ctx, client, logger)
+		if err != nil {
+			return err
+		}
+	}
+	err = registry.DeleteRegistries(ctx, client, logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Environment removed from context %s.", e.context)
 	return nil
 }
 
@@ -155,38 +231,190 @@ func (e *Environment) Setup(ctx context.Context, logger *zap.SugaredLogger) erro
 	}
 
 	var params map[string]any
+	version := e.engineVersion
 	release, err := installer.GetRelease()
 	if err == nil {
 		params = release.Config
+		installed := engine.ReleaseVersion(release)
+		if version == "" {
+			// Version pinned by kndp doesn't downgrade newer installed engine
+			if engine.CheckVersionChange(installed, engine.Version, false) != nil {
+				version = installed
+			}
+		} else if err := engine.CheckVersionChange(installed, version, e.force); err != nil {
+			return err
+		}
+	}
+
+	if e.values != nil {
+		params = chartutil.MergeTables(copyValues(e.values), params)
+	}
+
+	params, err = e.overrideValues(params)
+	if err != nil {
+		return err
 	}
 
 	logger.Debug("Installing engine")
-	err = engine.InstallEngine(ctx, configClient, params, logger)
+	err = engine.InstallEngine(ctx, configClient, version, params, logger)
+	if err != nil {
+		return err
+	}
+
+	err = e.setupRegistries(ctx, configClient, logger)
+	if err != nil {
+		return err
+	}
+
+	err = e.installAddons(configClient, logger)
 	if err != nil {
 		return err
 	}
+
+	if len(e.providers) > 0 {
+		logger.Debug("Applying providers")
+		err = provider.New("").ApplyProvider(ctx, e.providers, configClient, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(e.configurations) > 0 {
+		logger.Debug("Applying configurations")
+		err = configuration.ApplyConfiguration(ctx, strings.Join(e.configurations, ","), configClient, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.privileged.Kubernetes || e.privileged.Helm {
+		err = e.setupPrivilegedProviders(ctx, configClient, logger)
+		if err != nil {
+			return err
+		}
+	}
 	logger.Debug("Done")
 	return nil
 }
 
+// Create registries requested by environment, which are not exists yet
+func (e *Environment) setupRegistries(ctx context.Context, configClient *rest.Config, logger *zap.SugaredLogger) error {
+	if len(e.registries) == 0 {
+		return nil
+	}
+	client, err := kube.Client(configClient)
+	if err != nil {
+		return err
+	}
+	for _, spec := range e.registries {
+		reg, err := spec.registry()
+		if err != nil {
+			return err
+		}
+		reg.SetDefault(spec.Default)
+		if !spec.Local && reg.Exists(ctx, client) {
+			logger.Debugf("Registry %s already exists, skipping.", spec.Server)
+			continue
+		}
+		err = reg.Validate(ctx, client, logger)
+		if err != nil {
+			return err
+		}
+		err = reg.Create(ctx, configClient, logger)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Registry of spec, credentials are token, username and password or docker config
+func (spec RegistrySpec) registry() (registry.Registry, error) {
+	servers := append([]string{spec.Server}, spec.Servers...)
+	switch {
+	case spec.Local:
+		reg := registry.NewLocal()
+		reg.WithLocalOptions(spec.localOptions())
+		return reg, nil
+	case spec.DockerConfig:
+		return registry.NewFromDockerConfig(servers)
+	case spec.Token != "":
+		return registry.New(servers, registry.TokenAuth(spec.Username, spec.Token)), nil
+	default:
+		return registry.New(servers, registry.NewAuth(spec.Username, spec.Password, spec.Email)), nil
+	}
+}
+
+// Options of local registry spec, username and password enable its auth
+func (spec RegistrySpec) localOptions() registry.LocalOptions {
+	return registry.LocalOptions{
+		Storage:     spec.Storage,
+		StorageSize: spec.StorageSize,
+		TLS:         spec.TLS,
+		Username:    spec.Username,
+		Password:    spec.Password,
+	}
+}
+
+// Engine values overridden by values files and set parameters, in order of precedence
+func (e *Environment) overrideValues(params map[string]any) (map[string]any, error) {
+	if len(e.valueFiles) == 0 && len(e.overrides) == 0 {
+		return params, nil
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+	for _, file := range e.valueFiles {
+		values, err := chartutil.ReadValuesFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %v", file, err)
+		}
+		params = chartutil.MergeTables(values.AsMap(), params)
+	}
+	params, err := helm.NewParser(params, e.overrides).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse set values: %v", err)
+	}
+	return params, nil
+}
+
+// Deep copy of Helm values, to not modify values owned by environment
+func copyValues(values map[string]any) map[string]any {
+	copied := make(map[string]any, len(values))
+	for k, v := range values {
+		if m, ok := v.(map[string]any); ok {
+			v = copyValues(m)
+		}
+		copied[k] = v
+	}
+	return copied
+}
+
 // Get contect name specially for engine
 func (e *Environment) GetContextName() string {
-	var context string
-	switch e.engine {
-	case "kind":
-		context = e.KindContextName()
-	case "k3s":
-		context = e.K3sContextName()
-	case "k3d":
-		context = e.K3dContextName()
-	default:
+	engine, err := e.kubernetesEngine()
+	if err != nil {
 		return ""
 	}
-	return context
+	return engine.ContextName(e)
 }
 
-// Copy Environment from source to destination contexts
+// Copy Environment from source to destination contexts,
+// packages are copied and become healthy before composites.
+// Copy is refused when objects conflict with existing ones in destination, unless forced, conflicting objects are skipped then.
 func (e *Environment) CopyEnvironment(ctx context.Context, logger *zap.SugaredLogger, source string, destination string) error {
+	plan, err := e.PlanCopy(ctx, source, destination)
+	if err != nil {
+		return err
+	}
+	if plan.HasConflicts() {
+		for _, item := range plan.Conflicts() {
+			logger.Warnf("%s %s conflicts with destination: %s", item.Kind, item.Name, item.Message)
+		}
+		if !e.force {
+			return fmt.Errorf("%d objects conflict with destination, review them by dry run or use force to copy other objects", len(plan.Conflicts()))
+		}
+	}
 
 	// Create a REST clients
 	sourceConfig, err := kube.Config(source)
@@ -216,28 +444,80 @@ func (e *Environment) CopyEnvironment(ctx context.Context, logger *zap.SugaredLo
 	}
 
 	// Copy registries
-	err = registry.CopyRegistries(ctx, logger, sourceConfig, destConfig)
+	err = registry.CopyRegistries(ctx, logger, sourceConfig, destConfig, e.copyAllows(CheckRegistry))
 	if err != nil {
 		return err
 	}
 
 	// Copy engine
-	logger.Info("Start copy engine...")
-	sourceEngine, err := engine.GetEngine(sourceConfig)
+	if e.copyFilter.Allows(CheckEngine, engine.ReleaseName) {
+		logger.Info("Start copy engine...")
+		sourceEngine, err := engine.GetEngine(sourceConfig)
+		if err != nil {
+			return err
+		}
+
+		sourceRelease, err := sourceEngine.GetRelease()
+		if err != nil {
+			return err
+		}
+
+		destEngine, err := engine.GetEngine(destConfig)
+		if err != nil {
+			return err
+		}
+		if destRelease, err := destEngine.GetRelease(); err == nil {
+			err = engine.CheckVersionChange(engine.ReleaseVersion(destRelease), engine.ReleaseVersion(sourceRelease), e.force)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = engine.InstallEngine(ctx, destConfig, engine.ReleaseVersion(sourceRelease), sourceRelease.Config, logger)
+		if err != nil {
+			return err
+		}
+		logger.Info("Engine copied successfully!")
+	}
+
+	// Copy providers and wait until they are healthy
+	providers, err := e.copiedPackages(ctx, sourceContext, provider.ResourceId(), CheckProvider)
 	if err != nil {
 		return err
 	}
-
-	sourceRelease, err := sourceEngine.GetRelease()
+	err = provider.MoveProviders(ctx, logger, destinationContext, providers)
 	if err != nil {
 		return err
 	}
+	if len(providers) > 0 {
+		err = provider.HealthCheck(ctx, destinationContext, packageLinks(providers), time.After(copyHealthTimeout), logger)
+		if err != nil {
+			return err
+		}
+	}
 
-	engine.InstallEngine(ctx, destConfig, sourceRelease.Config, logger)
-	logger.Info("Engine copied successfully!")
+	// Copy configurations and wait until they are healthy
+	configurations, err := e.copiedPackages(ctx, sourceContext, configuration.ResourceId(), CheckConfiguration)
+	if err != nil {
+		return err
+	}
+	err = configuration.MoveConfigurations(ctx, logger, destinationContext, configurations, kube.ResourceParams{
+		Group:    configuration.ResourceId().Group,
+		Version:  configuration.ResourceId().Version,
+		Resource: configuration.ResourceId().Resource,
+	})
+	if err != nil {
+		return err
+	}
+	if len(configurations) > 0 {
+		err = configuration.HealthCheck(ctx, destinationContext, strings.Join(packageLinks(configurations), ","), true, time.After(copyHealthTimeout), logger)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Copy composities
-	err = resources.CopyComposites(ctx, logger, sourceContext, destinationContext)
+	err = resources.CopyComposites(ctx, logger, sourceContext, destinationContext, e.copyAllows(KindComposite))
 	if err != nil {
 		return err
 	}
@@ -247,30 +527,24 @@ func (e *Environment) CopyEnvironment(ctx context.Context, logger *zap.SugaredLo
 	return nil
 }
 
-// Start Environment
+// Start Environment and wait until API server is ready
 func (e *Environment) Start(ctx context.Context, switcher bool, logger *zap.SugaredLogger) error {
-	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv)
+	engine, err := e.kubernetesEngine()
 	if err != nil {
 		return err
 	}
-	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{All: true})
+	err = engine.Start(ctx, e, logger)
 	if err != nil {
 		return err
 	}
 
-	for _, c := range containers {
-		if strings.Contains(c.Names[0], e.name) {
-			containerID := c.ID
-			err := dockerClient.ContainerStart(ctx, containerID, types.ContainerStartOptions{})
-			if err != nil {
-				logger.Errorf("Environment possible doesn't exists or failed to start %s: %v", c.ID, err)
-				return err
-			}
-		}
+	err = e.waitApiServer(ctx, engine.ContextName(e), logger)
+	if err != nil {
+		return err
 	}
 
 	if switcher {
-		err := SwitchContext(e.GetContextName())
+		err := SwitchContext(engine.ContextName(e))
 		if err != nil {
 			return err
 		}
@@ -282,27 +556,51 @@ func (e *Environment) Start(ctx context.Context, switcher bool, logger *zap.Suga
 
 // Stop Environment
 func (e *Environment) Stop(ctx context.Context, logger *zap.SugaredLogger) error {
-	dockerClient, err := docker.NewClientWithOpts(docker.FromEnv)
+	engine, err := e.kubernetesEngine()
 	if err != nil {
 		return err
 	}
-	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{All: true})
+	err = engine.Stop(ctx, e, logger)
 	if err != nil {
 		return err
 	}
-	for _, c := range containers {
-		if strings.Contains(c.Names[0], e.name) {
-			containerID := c.ID
-			err := dockerClient.ContainerStop(ctx, containerID, container.StopOptions{})
-			if err != nil {
-				return err
-			}
-		}
-	}
 	logger.Info("Environment stopped successfully.")
 	return nil
 }
 
+// Restart Environment, stopped environment is started
+func (e *Environment) Restart(ctx context.Context, switcher bool, logger *zap.SugaredLogger) error {
+	err := e.Stop(ctx, logger)
+	if err != nil {
+		return err
+	}
+	return e.Start(ctx, switcher, logger)
+}
+
+// Poll readiness of API server in environment context until timeout
+func (e *Environment) waitApiServer(ctx context.Context, contextName string, logger *zap.SugaredLogger) error {
+	configClient, err := kube.Config(contextName)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, apiServerReadyTimeout)
+	defer cancel()
+
+	logger.Info("Waiting for API server to be ready")
+	for {
+		err := apiServerReady(ctx, configClient)
+		if err == nil {
+			return nil
+		}
+		logger.Debugf("API server is not ready yet: %v", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("API server of environment %s is not ready: %v", e.name, err)
+		case <-time.After(apiServerPollInterval):
+		}
+	}
+}
+
 func (e *Environment) WithHttpPort(port int) *Environment {
 	e.httpPort = port
 	return e
@@ -323,30 +621,77 @@ func (e *Environment) WithMountPath(path string) *Environment {
 	return e
 }
 
-func SwitchContext(name string) (err error) {
-	newConfig := clientcmd.GetConfigFromFileOrDie(clientcmd.RecommendedHomeFile)
-	newConfig.CurrentContext = name
-	err = clientcmd.ModifyConfig(clientcmd.NewDefaultPathOptions(), *newConfig, true)
-	return
+func (e *Environment) WithMounts(mounts ...Mount) *Environment {
+	e.mounts = append(e.mounts, mounts...)
+	return e
 }
 
-// List Environments in available contexts
-func ListEnvironments(logger *zap.SugaredLogger, tableData pterm.TableData) pterm.TableData {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = filepath.Join(os.Getenv("HOME"), ".kube", "config")
-	}
-	configFile := clientcmd.GetConfigFromFileOrDie(kubeconfig)
+// Uninstall engine from host on delete, if supported by engine
+func (e *Environment) WithUninstall(uninstall bool) *Environment {
+	e.uninstall = uninstall
+	return e
+}
 
-	for name := range configFile.Contexts {
-		configClient, err := config.GetConfigWithContext(name)
-		if err != nil {
-			logger.Fatal(err)
-		}
-		if engine.IsHelmReleaseFound(configClient) {
-			types := regexp.MustCompile(`(\w+)`).FindStringSubmatch(name)
-			tableData = append(tableData, []string{name, strings.ToUpper(types[0])})
-		}
-	}
-	return tableData
+func (e *Environment) WithTopology(topology Topology) *Environment {
+	e.topology = topology
+	return e
+}
+
+func (e *Environment) WithValues(values map[string]any) *Environment {
+	e.values = values
+	return e
+}
+
+func (e *Environment) WithProviders(providers ...string) *Environment {
+	e.providers = append(e.providers, providers...)
+	return e
+}
+
+func (e *Environment) WithConfigurations(configurations ...string) *Environment {
+	e.configurations = append(e.configurations, configurations...)
+	return e
+}
+
+func (e *Environment) WithRegistries(registries ...RegistrySpec) *Environment {
+	e.registries = append(e.registries, registries...)
+	return e
+}
+
+func (e *Environment) WithEngineVersion(version string) *Environment {
+	e.engineVersion = strings.TrimPrefix(version, "v")
+	return e
+}
+
+// Allow engine downgrade
+func (e *Environment) WithForce(force bool) *Environment {
+	e.force = force
+	return e
+}
+
+// Engine values files, later files take precedence
+func (e *Environment) WithValueFiles(files ...string) *Environment {
+	e.valueFiles = append(e.valueFiles, files...)
+	return e
+}
+
+// Engine values in helm-style key=value format, take precedence over values files
+func (e *Environment) WithOverrides(overrides map[string]string) *Environment {
+	e.overrides = overrides
+	return e
+}
+
+// Kubernetes and Helm providers with in-cluster provider configs
+func (e *Environment) WithPrivilegedProviders(privileged PrivilegedProvidersSpec) *Environment {
+	e.privileged = privileged
+	return e
+}
+
+func (e *Environment) WithTTL(ttl time.Duration) *Environment {
+	e.ttl = ttl
+	return e
+}
+
+func (e *Environment) WithCopyFilter(filter CopyFilter) *Environment {
+	e.copyFilter = filter
+	return e
 }
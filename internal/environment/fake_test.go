@@ -0,0 +1,91 @@
+package environment
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// FakeEngine is in memory Kubernetes engine, which could be registered for tests
+type FakeEngine struct {
+	mu       sync.Mutex
+	clusters map[string]EngineStatus
+
+	// Calls made to engine in format "<method> <environment name>"
+	Calls []string
+	// Error returned by all lifecycle methods when set
+	Err error
+}
+
+// New FakeEngine without clusters
+func NewFakeEngine() *FakeEngine {
+	return &FakeEngine{
+		clusters: map[string]EngineStatus{},
+	}
+}
+
+func (f *FakeEngine) Create(ctx context.Context, e *Environment, logger *zap.SugaredLogger) (string, error) {
+	if err := f.record("create", e, StatusRunning); err != nil {
+		return "", err
+	}
+	return f.ContextName(e), nil
+}
+
+func (f *FakeEngine) Delete(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return f.record("delete", e, StatusNotFound)
+}
+
+func (f *FakeEngine) Start(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return f.record("start", e, StatusRunning)
+}
+
+func (f *FakeEngine) Stop(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return f.record("stop", e, StatusStopped)
+}
+
+func (f *FakeEngine) ContextName(e *Environment) string {
+	return "fake-" + e.name
+}
+
+func (f *FakeEngine) Exists(ctx context.Context, e *Environment) (bool, error) {
+	status, _ := f.Status(ctx, e)
+	return status != StatusNotFound, nil
+}
+
+func (f *FakeEngine) Status(ctx context.Context, e *Environment) (EngineStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, ok := f.clusters[e.name]
+	if !ok {
+		return StatusNotFound, nil
+	}
+	return status, nil
+}
+
+func (f *FakeEngine) Clusters(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := []string{}
+	for name := range f.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *FakeEngine) record(method string, e *Environment, status EngineStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, method+" "+e.name)
+	if f.Err != nil {
+		return f.Err
+	}
+	if status == StatusNotFound {
+		delete(f.clusters, e.name)
+	} else {
+		f.clusters[e.name] = status
+	}
+	return nil
+}
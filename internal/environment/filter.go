@@ -0,0 +1,69 @@
+package environment
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+var copyKinds = []string{CheckRegistry, CheckEngine, CheckProvider, CheckConfiguration, KindComposite}
+
+// Filter of copied objects by rules in format "<kind>[:<name glob>]",
+// when include rules are present, only objects matched by them are copied.
+type CopyFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Validate kinds and name patterns of rules
+func (f CopyFilter) Validate() error {
+	for _, rule := range append(append([]string{}, f.Include...), f.Exclude...) {
+		kind, pattern, _ := strings.Cut(rule, ":")
+		if copyKind(kind) == "" {
+			return fmt.Errorf("unknown kind '%s' in filter '%s', available kinds: %s", kind, rule, strings.ToLower(strings.Join(copyKinds, ", ")))
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid name pattern in filter '%s': %v", rule, err)
+		}
+	}
+	return nil
+}
+
+// Check if object of kind with name is copied
+func (f CopyFilter) Allows(kind string, name string) bool {
+	for _, rule := range f.Exclude {
+		if matchRule(rule, kind, name) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, rule := range f.Include {
+		if matchRule(rule, kind, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRule(rule string, kind string, name string) bool {
+	ruleKind, pattern, _ := strings.Cut(rule, ":")
+	if copyKind(ruleKind) != kind {
+		return false
+	}
+	if pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+func copyKind(kind string) string {
+	for _, k := range copyKinds {
+		if strings.EqualFold(k, kind) {
+			return k
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package environment
+
+import "testing"
+
+func TestCopyFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  CopyFilter
+		wantErr bool
+	}{
+		{name: "empty", filter: CopyFilter{}},
+		{name: "kinds", filter: CopyFilter{Include: []string{"provider", "Configuration:platform-*"}, Exclude: []string{"registry:ghcr"}}},
+		{name: "unknown kind", filter: CopyFilter{Include: []string{"secret:*"}}, wantErr: true},
+		{name: "invalid pattern", filter: CopyFilter{Exclude: []string{"provider:["}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyFilterAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter CopyFilter
+		kind   string
+		object string
+		want   bool
+	}{
+		{name: "no rules", filter: CopyFilter{}, kind: CheckProvider, object: "provider-aws", want: true},
+		{name: "included kind", filter: CopyFilter{Include: []string{"provider"}}, kind: CheckProvider, object: "provider-aws", want: true},
+		{name: "not included kind", filter: CopyFilter{Include: []string{"provider"}}, kind: CheckConfiguration, object: "platform", want: false},
+		{name: "included name", filter: CopyFilter{Include: []string{"provider:provider-*"}}, kind: CheckProvider, object: "provider-aws", want: true},
+		{name: "not included name", filter: CopyFilter{Include: []string{"provider:provider-gcp"}}, kind: CheckProvider, object: "provider-aws", want: false},
+		{name: "excluded kind", filter: CopyFilter{Exclude: []string{"registry"}}, kind: CheckRegistry, object: "ghcr", want: false},
+		{name: "excluded name", filter: CopyFilter{Exclude: []string{"composite:test-*"}}, kind: KindComposite, object: "test-db", want: false},
+		{name: "exclude wins over include", filter: CopyFilter{Include: []string{"provider"}, Exclude: []string{"provider:provider-aws"}}, kind: CheckProvider, object: "provider-aws", want: false},
+		{name: "kind is case insensitive", filter: CopyFilter{Include: []string{"ENGINE"}}, kind: CheckEngine, object: "crossplane", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allows(tt.kind, tt.object); got != tt.want {
+				t.Errorf("Allows(%s, %s) = %v, want %v", tt.kind, tt.object, got, tt.want)
+			}
+		})
+	}
+}
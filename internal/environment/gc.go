@@ -0,0 +1,156 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	GarbageDelete        = "delete"
+	GarbageRemoveContext = "remove context"
+	// Cluster without context is deleted only when confirmed separately, its context could be in other kubeconfig
+	GarbageConfirmDelete = "delete if confirmed"
+)
+
+// Environment collected as garbage, expired or orphaned
+type Garbage struct {
+	Name      string `json:"name"`
+	Engine    string `json:"engine"`
+	Context   string `json:"context"`
+	MountPath string `json:"mountPath,omitempty"`
+	Action    string `json:"action"`
+	Reason    string `json:"reason"`
+}
+
+// Find expired environments of state, contexts of clusters which are gone and clusters without contexts.
+// Clusters without contexts are never collected without separate confirmation, as context could be in other kubeconfig.
+func FindGarbage(ctx context.Context, logger *zap.SugaredLogger) ([]Garbage, error) {
+	state, err := LoadState()
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	garbage := []Garbage{}
+	found := map[string]bool{}
+	for _, entry := range state.Environments {
+		kubernetesEngine, ok := engines[entry.Engine]
+		if !ok {
+			logger.Warnf("Kubernetes engine '%s' of environment %s not supported, skipped.", entry.Engine, entry.Name)
+			continue
+		}
+		e := New(entry.Engine, entry.Name).WithMountPath(entry.MountPath)
+		found[entry.Engine+"/"+entry.Name] = true
+		status, err := kubernetesEngine.Status(ctx, e)
+		if err != nil {
+			logger.Warnf("Status of environment %s unknown, skipped: %v", entry.Name, err)
+			continue
+		}
+		item := Garbage{Name: entry.Name, Engine: entry.Engine, Context: kubernetesEngine.ContextName(e), MountPath: entry.MountPath}
+		switch {
+		case status == StatusNotFound:
+			item.Action = GarbageRemoveContext
+			item.Reason = "cluster not found"
+		case entry.Expired(now):
+			item.Action = GarbageDelete
+			item.Reason = "expired at " + entry.ExpiresAt.Local().Format(time.RFC3339)
+		default:
+			continue
+		}
+		garbage = append(garbage, item)
+	}
+
+	// Contexts of local engine clusters, which are gone
+	for contextName := range kubeconfig.Contexts {
+		if !localContext(kubeconfig, contextName) {
+			continue
+		}
+		for _, p := range contextPrefixes() {
+			if p.prefix == "" || !strings.HasPrefix(contextName, p.prefix) {
+				continue
+			}
+			name := strings.TrimPrefix(contextName, p.prefix)
+			if found[p.engine+"/"+name] {
+				break
+			}
+			status, err := engines[p.engine].Status(ctx, New(p.engine, name))
+			if err != nil {
+				logger.Debugf("Status of context %s unknown, skipped: %v", contextName, err)
+				break
+			}
+			if status == StatusNotFound {
+				found[p.engine+"/"+name] = true
+				garbage = append(garbage, Garbage{Name: name, Engine: p.engine, Context: contextName, Action: GarbageRemoveContext, Reason: "cluster not found"})
+			}
+			break
+		}
+	}
+
+	// Clusters of container engines without contexts
+	for _, engineName := range Engines() {
+		lister, ok := engines[engineName].(clusterLister)
+		if !ok {
+			continue
+		}
+		clusters, err := lister.Clusters(ctx)
+		if err != nil {
+			logger.Debugf("Clusters of engine %s unknown, skipped: %v", engineName, err)
+			continue
+		}
+		for _, name := range clusters {
+			contextName := engines[engineName].ContextName(New(engineName, name))
+			if _, ok := kubeconfig.Contexts[contextName]; ok || found[engineName+"/"+name] {
+				continue
+			}
+			garbage = append(garbage, Garbage{Name: name, Engine: engineName, Context: contextName, Action: GarbageConfirmDelete, Reason: "context not found"})
+		}
+	}
+	return garbage, nil
+}
+
+// Delete garbage environments with engine, or remove their contexts
+func CollectGarbage(ctx context.Context, garbage []Garbage, f bool, logger *zap.SugaredLogger) error {
+	if !f && !confirmationPrompt(fmt.Sprintf("Do you really want to collect %d environments ?", len(garbage)), logger) {
+		return nil
+	}
+	for _, item := range garbage {
+		e := New(item.Engine, item.Name).WithMountPath(item.MountPath)
+		switch item.Action {
+		case GarbageDelete:
+			logger.Infof("Deleting environment %s, %s", item.Name, item.Reason)
+			if err := e.Delete(ctx, true, logger); err != nil {
+				return fmt.Errorf("failed to delete environment %s: %v", item.Name, err)
+			}
+		case GarbageConfirmDelete:
+			if f {
+				logger.Warnf("Skipping environment %s, %s, deletion of cluster without context must be confirmed by prompt", item.Name, item.Reason)
+				continue
+			}
+			if !confirmationPrompt(fmt.Sprintf("Cluster of environment %s has no context, it could be used from other kubeconfig. Do you really want to delete it ?", item.Name), logger) {
+				continue
+			}
+			logger.Infof("Deleting environment %s, %s", item.Name, item.Reason)
+			if err := e.Delete(ctx, true, logger); err != nil {
+				return fmt.Errorf("failed to delete environment %s: %v", item.Name, err)
+			}
+		case GarbageRemoveContext:
+			logger.Infof("Removing context %s, %s", item.Context, item.Reason)
+			if err := removeContext(item.Context); err != nil {
+				return err
+			}
+			if err := e.forgetState(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
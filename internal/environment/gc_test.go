@@ -0,0 +1,118 @@
+package environment
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Use temporary kubeconfig with contexts of clusters served by servers for test
+func tempKubeconfig(t *testing.T, servers map[string]string) string {
+	t.Helper()
+	config := clientcmdapi.NewConfig()
+	for name, server := range servers {
+		config.Clusters[name] = &clientcmdapi.Cluster{Server: server}
+		config.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: "token"}
+		config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	return path
+}
+
+func TestGarbage(t *testing.T) {
+	tempState(t)
+	path := tempKubeconfig(t, map[string]string{
+		"fake-expired": "https://127.0.0.1:6443",
+		"fake-gone":    "https://127.0.0.1:6444",
+		"fake-orphan":  "https://localhost:6445",
+		"fake-remote":  "https://cluster.example.com:6443",
+		"fake-running": "https://0.0.0.0:6446",
+	})
+	fake := registerFakeEngine(t, "fake")
+	fake.clusters["expired"] = StatusStopped
+	fake.clusters["running"] = StatusRunning
+	fake.clusters["nocontext"] = StatusRunning
+	fake.clusters["container"] = StatusStopped
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	state := &State{}
+	state.Add(StateEntry{Name: "expired", Engine: "fake", ExpiresAt: &past, MountPath: "/tmp/expired"})
+	state.Add(StateEntry{Name: "gone", Engine: "fake", ExpiresAt: &future})
+	state.Add(StateEntry{Name: "running", Engine: "fake", ExpiresAt: &future})
+	state.Add(StateEntry{Name: "nocontext", Engine: "fake"})
+	state.Add(StateEntry{Name: "unsupported", Engine: "unknown", ExpiresAt: &past})
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+	garbage, err := FindGarbage(ctx, logger)
+	if err != nil {
+		t.Fatalf("FindGarbage() error = %v", err)
+	}
+	// Clusters of other engines on host aren't collected by test
+	fakeGarbage := []Garbage{}
+	for _, item := range garbage {
+		if item.Engine == "fake" {
+			fakeGarbage = append(fakeGarbage, item)
+		}
+	}
+	garbage = fakeGarbage
+	sort.Slice(garbage, func(i, j int) bool {
+		return garbage[i].Name < garbage[j].Name
+	})
+	want := []Garbage{
+		{Name: "container", Engine: "fake", Context: "fake-container", Action: GarbageConfirmDelete, Reason: "context not found"},
+		{Name: "expired", Engine: "fake", Context: "fake-expired", MountPath: "/tmp/expired", Action: GarbageDelete, Reason: "expired at " + past.Local().Format(time.RFC3339)},
+		{Name: "gone", Engine: "fake", Context: "fake-gone", Action: GarbageRemoveContext, Reason: "cluster not found"},
+		{Name: "orphan", Engine: "fake", Context: "fake-orphan", Action: GarbageRemoveContext, Reason: "cluster not found"},
+	}
+	if !reflect.DeepEqual(garbage, want) {
+		t.Fatalf("FindGarbage() = %+v, want %+v", garbage, want)
+	}
+
+	// Cluster without context is skipped without confirmation by prompt
+	if err := CollectGarbage(ctx, garbage, true, logger); err != nil {
+		t.Fatalf("CollectGarbage() error = %v", err)
+	}
+	if want := []string{"delete expired"}; !reflect.DeepEqual(fake.Calls, want) {
+		t.Errorf("engine calls = %v, want %v", fake.Calls, want)
+	}
+
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contexts := []string{}
+	for name := range kubeconfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	if want := []string{"fake-expired", "fake-remote", "fake-running"}; !reflect.DeepEqual(contexts, want) {
+		t.Errorf("contexts = %v, want %v", contexts, want)
+	}
+
+	state, err = LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range state.Environments {
+		names = append(names, entry.Name)
+	}
+	if want := []string{"nocontext", "running", "unsupported"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("state environments = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,94 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+)
+
+// Revision of engine release with values changed since previous revision
+type Revision struct {
+	Revision    int         `json:"revision"`
+	Updated     time.Time   `json:"updated"`
+	Status      string      `json:"status"`
+	Version     string      `json:"version"`
+	AppVersion  string      `json:"appVersion"`
+	Description string      `json:"description"`
+	Diff        []FieldDiff `json:"diff,omitempty"`
+}
+
+// Revisions of engine release, oldest first
+func (e *Environment) History(ctx context.Context) ([]Revision, error) {
+	configClient, err := e.contextConfig()
+	if err != nil {
+		return nil, err
+	}
+	installer, err := engine.GetEngine(configClient)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := installer.History()
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []Revision{}
+	var previous map[string]any
+	for _, rel := range releases {
+		revision := Revision{
+			Revision: rel.Version,
+			Version:  engine.ReleaseVersion(rel),
+		}
+		if rel.Info != nil {
+			revision.Updated = rel.Info.LastDeployed.Time
+			revision.Status = string(rel.Info.Status)
+			revision.Description = rel.Info.Description
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			revision.AppVersion = rel.Chart.Metadata.AppVersion
+		}
+		revision.Diff = DiffValues(rel.Config, previous)
+		previous = rel.Config
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// Rollback engine release to revision, previous revision is used for 0
+func (e *Environment) Rollback(ctx context.Context, revision int, logger *zap.SugaredLogger) error {
+	configClient, err := e.contextConfig()
+	if err != nil {
+		return err
+	}
+	installer, err := engine.GetEngine(configClient)
+	if err != nil {
+		return err
+	}
+	if revision == 0 {
+		logger.Info("Rolling back engine to previous revision")
+	} else {
+		logger.Infof("Rolling back engine to revision %d", revision)
+	}
+	err = installer.Rollback(revision)
+	if err != nil {
+		return err
+	}
+	logger.Info("Engine rolled back successfully.")
+	return nil
+}
+
+// REST config of environment context, resolved by engine when context is not set
+func (e *Environment) contextConfig() (*rest.Config, error) {
+	if e.context == "" {
+		e.context = e.GetContextName()
+		if e.context == "" {
+			return nil, fmt.Errorf("Kubernetes engine '%s' not supported", e.engine)
+		}
+	}
+	return kube.Config(e.context)
+}
@@ -2,30 +2,115 @@ package environment
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 
+	"github.com/kndpio/kndp/internal/registry"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	k3dClusterLabel = "k3d.cluster"
+	k3sNodeImage    = "rancher/k3s"
+)
+
+type k3dEngine struct{}
+
+func init() {
+	RegisterEngine("k3d", k3dEngine{})
+}
+
+func (k3dEngine) Create(ctx context.Context, e *Environment, logger *zap.SugaredLogger) (string, error) {
+	return e.CreateK3dEnvironment(logger)
+}
+
+func (k3dEngine) Delete(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.DeleteK3dEnvironment(logger)
+}
+
+func (k3dEngine) Start(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.startContainers(ctx, k3dClusterLabel, logger)
+}
+
+func (k3dEngine) Stop(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.stopContainers(ctx, k3dClusterLabel, logger)
+}
+
+func (k3dEngine) ContextName(e *Environment) string {
+	return e.K3dContextName()
+}
+
+func (k3dEngine) Exists(ctx context.Context, e *Environment) (bool, error) {
+	err := exec.CommandContext(ctx, "k3d", "cluster", "get", e.name).Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (k3dEngine) Status(ctx context.Context, e *Environment) (EngineStatus, error) {
+	return containersStatus(ctx, k3dClusterLabel, e.name)
+}
+
+func (k3dEngine) Clusters(ctx context.Context) ([]string, error) {
+	return labeledClusters(ctx, k3dClusterLabel)
+}
+
 func (e *Environment) CreateK3dEnvironment(logger *zap.SugaredLogger) (string, error) {
+	if err := e.topology.Validate(); err != nil {
+		return "", err
+	}
+
+	registryConfig, err := e.k3dRegistryConfig()
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(registryConfig)
 
 	args := []string{
 		"cluster", "create", e.name,
+		"--servers", strconv.Itoa(e.topology.controlPlanes()),
+		"--agents", strconv.Itoa(e.topology.workers()),
+		"--port", fmt.Sprintf("%d:80@loadbalancer", e.httpPort),
+		"--port", fmt.Sprintf("%d:443@loadbalancer", e.httpsPort),
+		"--registry-config", registryConfig,
+	}
+
+	if image := e.k3dImage(); image != "" {
+		args = append(args, "--image", image)
 	}
 
 	if e.mountPath != "" {
-		args = append(args, "-v", e.mountPath+":/storage")
+		args = append(args, "--volume", e.mountPath+":/storage@all")
+	}
+	for _, mount := range e.mounts {
+		args = append(args, "--volume", mount.HostPath+":"+mount.ContainerPath+"@all")
 	}
 
+	nodeArgs, err := e.k3dNodeArgs()
+	if err != nil {
+		return "", err
+	}
+	args = append(args, nodeArgs...)
+	if e.options.ingressController != "" && e.options.ingressController != AddonNone {
+		// Bundled traefik occupies load balancer ports of requested ingress controller
+		args = append(args, "--k3s-arg", "--disable=traefik@server:*")
+	}
+
+	logger.Debugf("Running k3d %s", strings.Join(args, " "))
 	cmd := exec.Command("k3d", args...)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
-		logger.Fatalf("Error creating k3d cluster: %v", err)
+		return "", fmt.Errorf("error creating k3d cluster: %v", err)
 	}
 
 	logger.Info("k3d cluster created successfully")
@@ -38,13 +123,105 @@ func (e *Environment) DeleteK3dEnvironment(logger *zap.SugaredLogger) error {
 	if err != nil {
 		return err
 	}
-	cmd.Start()
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
 
 	stderrScanner := bufio.NewScanner(stderr)
 	for stderrScanner.Scan() {
 		logger.Info(stderrScanner.Text())
 	}
-	return nil
+	return cmd.Wait()
+}
+
+// Image of k3s nodes requested directly or by Kubernetes version
+func (e *Environment) k3dImage() string {
+	if e.topology.Image != "" {
+		return e.topology.Image
+	}
+	if e.topology.KubernetesVersion != "" {
+		return k3sNodeImage + ":v" + strings.TrimPrefix(e.topology.KubernetesVersion, "v") + "-k3s1"
+	}
+	return ""
+}
+
+// Labels and taints of nodes as k3s arguments with k3d node filters
+func (e *Environment) k3dNodeArgs() ([]string, error) {
+	args := []string{}
+	roles := []struct {
+		role   string
+		filter string
+		count  int
+	}{
+		{RoleControlPlane, "server", e.topology.controlPlanes()},
+		{RoleWorker, "agent", e.topology.workers()},
+	}
+	for _, r := range roles {
+		for i := 0; i < r.count; i++ {
+			labels, taints, err := e.topology.node(r.role, i)
+			if err != nil {
+				return nil, err
+			}
+			filter := fmt.Sprintf("@%s:%d", r.filter, i)
+			for _, label := range strings.Split(joinLabels(labels), ",") {
+				if label != "" {
+					args = append(args, "--k3s-node-label", label+filter)
+				}
+			}
+			for _, taint := range taints {
+				args = append(args, "--k3s-arg", "--node-taint="+taint.ToString()+filter)
+			}
+		}
+	}
+	return args, nil
+}
+
+// Write k3s registries config, which mirrors in cluster local registry domain
+// to its node port, so nodes are able to pull images loaded to local registry.
+func (e *Environment) k3dRegistryConfig() (string, error) {
+	local := RegistrySpec{}
+	for _, spec := range e.registries {
+		if spec.Local {
+			local = spec
+		}
+	}
+	scheme := "http"
+	if local.TLS {
+		scheme = "https"
+	}
+	endpoint := fmt.Sprintf("localhost:%d", registry.LocalNodePort)
+	config := map[string]any{
+		"mirrors": map[string]any{
+			registry.DefaultLocalDomain: map[string]any{
+				"endpoint": []string{
+					scheme + "://" + endpoint,
+				},
+			},
+		},
+	}
+	endpointConfig := map[string]any{}
+	if local.TLS {
+		// Certificate of local registry is created after cluster, so nodes can't trust it
+		endpointConfig["tls"] = map[string]any{"insecure_skip_verify": true}
+	}
+	if local.Username != "" {
+		endpointConfig["auth"] = map[string]any{"username": local.Username, "password": local.Password}
+	}
+	if len(endpointConfig) > 0 {
+		config["configs"] = map[string]any{endpoint: endpointConfig}
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp("", "kndp-k3d-registries-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return file.Name(), err
 }
 
 func (e *Environment) K3dContextName() string {
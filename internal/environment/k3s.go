@@ -1,14 +1,85 @@
 package environment
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/kndpio/kndp/internal/kube"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
-func (e *Environment) CreateK3sEnvironment(logger *zap.SugaredLogger) (string, error) {
+const (
+	k3sKubeconfig      = "/etc/rancher/k3s/k3s.yaml"
+	k3sDefaultDataDir  = "/var/lib/rancher/k3s"
+	k3sUninstallScript = "/usr/local/bin/k3s-uninstall.sh"
+	k3sReadyTimeout    = 5 * time.Minute
+	k3sPollInterval    = 2 * time.Second
+)
+
+type k3sEngine struct{}
+
+func init() {
+	RegisterEngine("k3s", k3sEngine{})
+}
+
+func (k3sEngine) Create(ctx context.Context, e *Environment, logger *zap.SugaredLogger) (string, error) {
+	return e.CreateK3sEnvironment(ctx, logger)
+}
+
+func (k3sEngine) Delete(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.DeleteK3sEnvironment(ctx, logger)
+}
+
+func (k3sEngine) Start(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.StartK3sEnvironment(ctx, logger)
+}
+
+func (k3sEngine) Stop(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	running, err := e.isK3sRunning(ctx)
+	if err != nil || !running {
+		return err
+	}
+	return e.stopK3s(ctx, logger)
+}
+
+func (k3sEngine) ContextName(e *Environment) string {
+	return e.K3sContextName()
+}
+
+func (k3sEngine) Exists(ctx context.Context, e *Environment) (bool, error) {
+	status, err := k3sEngine{}.Status(ctx, e)
+	return status == StatusRunning || status == StatusStopped, err
+}
+
+// Status of k3s server process, stopped if only data directory exists
+func (k3sEngine) Status(ctx context.Context, e *Environment) (EngineStatus, error) {
+	running, err := e.isK3sRunning(ctx)
+	if err != nil {
+		return StatusUnknown, err
+	}
+	if running {
+		return StatusRunning, nil
+	}
+	dataDir, err := e.k3sDataDir()
+	if err != nil {
+		return StatusUnknown, err
+	}
+	if _, err := os.Stat(dataDir); err == nil {
+		return StatusStopped, nil
+	}
+	return StatusNotFound, nil
+}
+
+func (e *Environment) CreateK3sEnvironment(ctx context.Context, logger *zap.SugaredLogger) (string, error) {
 
 	args := []string{
 		"k3s", "server",
@@ -20,31 +91,346 @@ func (e *Environment) CreateK3sEnvironment(logger *zap.SugaredLogger) (string, e
 	if e.mountPath != "" {
 		args = append(args, "--data-dir", e.mountPath)
 	}
+	if e.options.ingressController != "" && e.options.ingressController != AddonNone {
+		// Bundled traefik occupies service load balancer ports of requested ingress controller
+		args = append(args, "--disable", "traefik")
+	}
+	if len(e.mounts) > 0 {
+		logger.Warn("Mounts are not supported by k3s engine, host paths are available directly.")
+	}
 
-	cmd := exec.Command("sudo", args...)
+	err := e.startK3sServer(ctx, args, logger)
+	if err != nil {
+		return "", err
+	}
 
-	// Set the KUBECONFIG environment variable for the k3s process only
-	if os.Getenv("KUBECONFIG") == "" {
-		os.Setenv("KUBECONFIG", "/etc/rancher/k3s/k3s.yaml")
+	// Server arguments are kept to start environment with the same options later
+	err = e.saveK3sArgs(args)
+	if err != nil {
+		logger.Warnf("Failed to save k3s server arguments: %v", err)
 	}
+	return e.K3sContextName(), nil
+}
+
+// Start stopped k3s server with arguments of environment creation
+func (e *Environment) StartK3sEnvironment(ctx context.Context, logger *zap.SugaredLogger) error {
+	running, err := e.isK3sRunning(ctx)
+	if err != nil {
+		return err
+	}
+	if running {
+		logger.Info("k3s server is already running")
+		return nil
+	}
+
+	args, err := e.loadK3sArgs()
+	if err != nil {
+		return err
+	}
+	dataDir := k3sArgsDataDir(args)
+	if _, err := os.Stat(dataDir); err != nil {
+		return fmt.Errorf("k3s environment %s not found, data directory %s doesn't exist", e.name, dataDir)
+	}
+	return e.startK3sServer(ctx, args, logger)
+}
 
-	err := cmd.Start()
+// Run k3s server in background and wait until it is ready
+func (e *Environment) startK3sServer(ctx context.Context, args []string, logger *zap.SugaredLogger) error {
+	logFile, err := e.k3sLogFile()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer logFile.Close()
+
+	cmd := exec.Command("sudo", args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
 
-	// Wait for some time
-	time.Sleep(10 * time.Second)
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	logger.Infof("k3s server started, logs are written to %s", logFile.Name())
 
-	logger.Info("k3s server started successfully")
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
 
-	return e.K3sContextName(), nil
+	err = e.waitK3sReady(ctx, exited, logger)
+	if err != nil {
+		return err
+	}
+
+	err = e.mergeK3sKubeconfig()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("k3s server is ready")
+	return nil
 }
 
-func (e *Environment) DeleteK3sEnvironment(logger *zap.SugaredLogger) error {
+// Delete k3s environment, stop server and clean data directory or uninstall k3s
+func (e *Environment) DeleteK3sEnvironment(ctx context.Context, logger *zap.SugaredLogger) error {
+	if e.uninstall {
+		if _, err := os.Stat(k3sUninstallScript); err != nil {
+			return fmt.Errorf("k3s uninstall script %s not found", k3sUninstallScript)
+		}
+		logger.Info("Uninstalling k3s")
+		err := e.runK3sCommand(ctx, logger, "sudo", k3sUninstallScript)
+		if err != nil {
+			return err
+		}
+	} else {
+		dataDir, err := e.k3sDataDir()
+		if err != nil {
+			return err
+		}
+		logger.Info("Stopping k3s server")
+		err = e.stopK3s(ctx, logger)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+			logger.Warnf("k3s data directory %s not found", dataDir)
+		} else {
+			if err := checkK3sDataDir(dataDir); err != nil {
+				return err
+			}
+			logger.Infof("Cleaning k3s data directory %s", dataDir)
+			err = e.runK3sCommand(ctx, logger, "sudo", "rm", "-rf", dataDir)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err := removeContext(e.K3sContextName())
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(e.k3sArgsFile()); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("Failed to remove k3s server arguments: %v", err)
+	}
+	logger.Info("k3s environment deleted successfully")
 	return nil
 }
 
 func (e *Environment) K3sContextName() string {
 	return e.name
 }
+
+// Stop k3s server of environment, servers of other environments on host are kept running
+func (e *Environment) stopK3s(ctx context.Context, logger *zap.SugaredLogger) error {
+	running, err := e.isK3sRunning(ctx)
+	if err != nil || !running {
+		return err
+	}
+	return e.runK3sCommand(ctx, logger, "sudo", "pkill", "-f", e.k3sProcessPattern())
+}
+
+func (e *Environment) isK3sRunning(ctx context.Context) (bool, error) {
+	err := exec.CommandContext(ctx, "pgrep", "-f", e.k3sProcessPattern()).Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Extended regular expression of k3s server process of environment, names which start with environment name aren't matched
+func (e *Environment) k3sProcessPattern() string {
+	return "k3s server .*--node-name " + regexp.QuoteMeta(e.name) + "( |$)"
+}
+
+// Data directory of environment from saved server arguments
+func (e *Environment) k3sDataDir() (string, error) {
+	args, err := e.loadK3sArgs()
+	if err != nil {
+		return "", err
+	}
+	return k3sArgsDataDir(args), nil
+}
+
+// Data directory of k3s server arguments, default directory when it isn't set
+func k3sArgsDataDir(args []string) string {
+	dataDir := k3sDefaultDataDir
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--data-dir" {
+			dataDir = args[i+1]
+		}
+	}
+	return dataDir
+}
+
+// Data directory is removed only if it is k3s data directory,
+// root, home and their parent directories are never removed.
+func checkK3sDataDir(dataDir string) error {
+	path, err := filepath.Abs(dataDir)
+	if err != nil {
+		return err
+	}
+	unsafe := []string{string(filepath.Separator)}
+	if home, err := os.UserHomeDir(); err == nil {
+		unsafe = append(unsafe, home)
+	}
+	for _, dir := range unsafe {
+		if rel, err := filepath.Rel(path, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("refusing to remove %s, it contains %s", path, dir)
+		}
+	}
+	for _, dir := range []string{"server", "agent"} {
+		if _, err := os.Stat(filepath.Join(path, dir)); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing to remove %s, it is not k3s data directory", path)
+}
+
+func (e *Environment) k3sLogFile() (*os.File, error) {
+	dir := filepath.Join(os.TempDir(), "kndp")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(filepath.Join(dir, "k3s-"+e.name+".log"))
+}
+
+// Server arguments are stored in user config directory, data directory of k3s is owned by root
+func (e *Environment) k3sArgsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "kndp", "k3s-"+e.name+".yaml")
+}
+
+func (e *Environment) saveK3sArgs(args []string) error {
+	data, err := yaml.Marshal(args)
+	if err != nil {
+		return err
+	}
+	path := e.k3sArgsFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Saved server arguments, or arguments of environment options when they are not saved
+func (e *Environment) loadK3sArgs() ([]string, error) {
+	data, err := os.ReadFile(e.k3sArgsFile())
+	if os.IsNotExist(err) {
+		args := []string{"k3s", "server", "--write-kubeconfig-mode", "0644", "--node-name", e.name}
+		if e.mountPath != "" {
+			args = append(args, "--data-dir", e.mountPath)
+		}
+		return args, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	args := []string{}
+	if err := yaml.Unmarshal(data, &args); err != nil {
+		return nil, fmt.Errorf("failed to parse k3s server arguments %s: %v", e.k3sArgsFile(), err)
+	}
+	if len(args) < 2 || args[0] != "k3s" || args[1] != "server" {
+		return nil, fmt.Errorf("k3s server arguments %s are invalid, they should start with 'k3s server'", e.k3sArgsFile())
+	}
+	return args, nil
+}
+
+func (e *Environment) runK3sCommand(ctx context.Context, logger *zap.SugaredLogger, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if len(out) > 0 {
+		logger.Debug(string(out))
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %v", name, err)
+	}
+	return nil
+}
+
+// Poll k3s API server until it is ready, process exited or timeout reached
+func (e *Environment) waitK3sReady(ctx context.Context, exited <-chan error, logger *zap.SugaredLogger) error {
+	ctx, cancel := context.WithTimeout(ctx, k3sReadyTimeout)
+	defer cancel()
+	ticker := time.NewTicker(k3sPollInterval)
+	defer ticker.Stop()
+
+	logger.Info("Waiting for k3s API server to be ready")
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("k3s server exited before ready: %v", err)
+		case <-ctx.Done():
+			return fmt.Errorf("k3s API server is not ready: %v", ctx.Err())
+		case <-ticker.C:
+			config, err := kube.GetKubeConfig(k3sKubeconfig)
+			if err == nil {
+				err = apiServerReady(ctx, config)
+			}
+			if err != nil {
+				logger.Debugf("k3s API server is not ready yet: %v", err)
+				continue
+			}
+			return nil
+		}
+	}
+}
+
+// Check readiness endpoint of API server
+func apiServerReady(ctx context.Context, config *rest.Config) error {
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
+	}
+	_, err = client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+	return err
+}
+
+// Merge k3s kubeconfig to user kubeconfig as context named by environment
+func (e *Environment) mergeK3sKubeconfig() error {
+	k3sConfig, err := clientcmd.LoadFromFile(k3sKubeconfig)
+	if err != nil {
+		return err
+	}
+	k3sContext, ok := k3sConfig.Contexts[k3sConfig.CurrentContext]
+	if !ok {
+		return fmt.Errorf("current context not found in %s", k3sKubeconfig)
+	}
+
+	name := e.K3sContextName()
+	po := clientcmd.NewDefaultPathOptions()
+	config, err := po.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+	config.Clusters[name] = k3sConfig.Clusters[k3sContext.Cluster]
+	config.AuthInfos[name] = k3sConfig.AuthInfos[k3sContext.AuthInfo]
+	k3sContext.Cluster = name
+	k3sContext.AuthInfo = name
+	config.Contexts[name] = k3sContext
+	config.CurrentContext = name
+	return clientcmd.ModifyConfig(po, *config, true)
+}
+
+// Remove context with cluster and user from user kubeconfig
+func removeContext(name string) error {
+	po := clientcmd.NewDefaultPathOptions()
+	config, err := po.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+	context, ok := config.Contexts[name]
+	if !ok {
+		return nil
+	}
+	delete(config.Clusters, context.Cluster)
+	delete(config.AuthInfos, context.AuthInfo)
+	delete(config.Contexts, name)
+	if config.CurrentContext == name {
+		config.CurrentContext = ""
+	}
+	return clientcmd.ModifyConfig(po, *config, true)
+}
@@ -0,0 +1,34 @@
+package environment
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestK3sProcessPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    bool
+	}{
+		{name: "last argument", command: "/usr/local/bin/k3s server --node-name dev", want: true},
+		{name: "middle argument", command: "/usr/local/bin/k3s server --node-name dev --data-dir /var/lib/rancher/k3s", want: true},
+		{name: "longer name", command: "/usr/local/bin/k3s server --node-name dev2", want: false},
+		{name: "longer name in middle", command: "/usr/local/bin/k3s server --node-name dev2 --disable traefik", want: false},
+		{name: "agent", command: "/usr/local/bin/k3s agent --node-name dev", want: false},
+	}
+	pattern := regexp.MustCompile(New("k3s", "dev").k3sProcessPattern())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pattern.MatchString(tt.command); got != tt.want {
+				t.Errorf("pattern %s matches %q = %v, want %v", pattern, tt.command, got, tt.want)
+			}
+		})
+	}
+
+	// Special characters of name are matched literally
+	pattern = regexp.MustCompile(New("k3s", "dev.1").k3sProcessPattern())
+	if pattern.MatchString("k3s server --node-name devx1") {
+		t.Errorf("pattern %s matches other name", pattern)
+	}
+}
@@ -2,6 +2,7 @@ package environment
 
 import (
 	"bufio"
+	"context"
 	"os/exec"
 	"strings"
 
@@ -17,6 +18,7 @@ type KindCluster struct {
 
 type KindNode struct {
 	Role                 string            `yaml:"role"`
+	Image                string            `yaml:"image,omitempty"`
 	ExtraMounts          []KindMount       `yaml:"extraMounts,omitempty"`
 	KubeadmConfigPatches []string          `yaml:"kubeadmConfigPatches,omitempty"`
 	ExtraPortMappings    []KindPortMapping `yaml:"extraPortMappings,omitempty"`
@@ -33,9 +35,78 @@ type KindPortMapping struct {
 	Protocol      string `yaml:"protocol"`
 }
 
+const kindClusterLabel = "io.x-k8s.kind.cluster"
+
+type kindEngine struct{}
+
+func init() {
+	RegisterEngine("kind", kindEngine{})
+}
+
+func (kindEngine) Create(ctx context.Context, e *Environment, logger *zap.SugaredLogger) (string, error) {
+	return e.CreateKindEnvironment(logger)
+}
+
+func (kindEngine) Delete(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.DeleteKindEnvironment(logger)
+}
+
+func (kindEngine) Start(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.startContainers(ctx, kindClusterLabel, logger)
+}
+
+func (kindEngine) Stop(ctx context.Context, e *Environment, logger *zap.SugaredLogger) error {
+	return e.stopContainers(ctx, kindClusterLabel, logger)
+}
+
+func (kindEngine) ContextName(e *Environment) string {
+	return e.KindContextName()
+}
+
+func (kindEngine) Exists(ctx context.Context, e *Environment) (bool, error) {
+	out, err := exec.CommandContext(ctx, "kind", "get", "clusters").Output()
+	if err != nil {
+		return false, err
+	}
+	for _, cluster := range strings.Fields(string(out)) {
+		if cluster == e.name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (kindEngine) Status(ctx context.Context, e *Environment) (EngineStatus, error) {
+	return containersStatus(ctx, kindClusterLabel, e.name)
+}
+
+func (kindEngine) Clusters(ctx context.Context) ([]string, error) {
+	return labeledClusters(ctx, kindClusterLabel)
+}
+
+type kubeadmPatch struct {
+	Kind             string                  `yaml:"kind"`
+	NodeRegistration kubeadmNodeRegistration `yaml:"nodeRegistration"`
+}
+
+type kubeadmNodeRegistration struct {
+	KubeletExtraArgs map[string]string `yaml:"kubeletExtraArgs,omitempty"`
+	Taints           []kubeadmTaint    `yaml:"taints,omitempty"`
+}
+
+type kubeadmTaint struct {
+	Key    string `yaml:"key"`
+	Value  string `yaml:"value,omitempty"`
+	Effect string `yaml:"effect"`
+}
+
 func (e *Environment) CreateKindEnvironment(logger *zap.SugaredLogger) (string, error) {
 
-	clusterYaml := e.configYaml(logger)
+	clusterYaml, err := e.configYaml()
+	if err != nil {
+		return "", err
+	}
+	logger.Debugf("Kind cluster config:\n%s", clusterYaml)
 
 	cmd := exec.Command("kind", "create", "cluster", "--name", e.name, "--config", "-")
 	cmd.Stdin = strings.NewReader(clusterYaml)
@@ -96,50 +167,108 @@ func (e *Environment) KindContextName() string {
 }
 
 // Return YAML of cluster config file
-func (e *Environment) configYaml(logger *zap.SugaredLogger) string {
+func (e *Environment) configYaml() (string, error) {
+	if err := e.topology.Validate(); err != nil {
+		return "", err
+	}
 
 	template := KindCluster{
 		Kind:       "Cluster",
 		APIVersion: "kind.x-k8s.io/v1alpha4",
-		Nodes: []KindNode{
-			{
-				Role:        "worker",
-				ExtraMounts: []KindMount{},
-			},
-			{
-				Role: "control-plane",
-				KubeadmConfigPatches: []string{
-					`kind: InitConfiguration
-nodeRegistration:
-  kubeletExtraArgs:
-    node-labels: "ingress-ready=true"`,
-				},
-				ExtraPortMappings: []KindPortMapping{
-					{
-						ContainerPort: 80,
-						HostPort:      e.httpPort,
-						Protocol:      "TCP",
-					},
-					{
-						ContainerPort: 443,
-						HostPort:      e.httpsPort,
-						Protocol:      "TCP",
-					},
-				},
-			},
-		},
+		Nodes:      []KindNode{},
 	}
 
+	mounts := []KindMount{}
 	if e.mountPath != "" {
-		template.Nodes[0].ExtraMounts = append(template.Nodes[0].ExtraMounts, KindMount{
+		mounts = append(mounts, KindMount{
 			HostPath:      e.mountPath,
 			ContainerPath: "/storage",
 		})
 	}
+	for _, mount := range e.mounts {
+		mounts = append(mounts, KindMount{
+			HostPath:      mount.HostPath,
+			ContainerPath: mount.ContainerPath,
+		})
+	}
+
+	workers := e.topology.workers()
+	for i := 0; i < e.topology.controlPlanes(); i++ {
+		node, err := e.kindNode(RoleControlPlane, i)
+		if err != nil {
+			return "", err
+		}
+		if i == 0 {
+			node.ExtraPortMappings = []KindPortMapping{
+				{
+					ContainerPort: 80,
+					HostPort:      e.httpPort,
+					Protocol:      "TCP",
+				},
+				{
+					ContainerPort: 443,
+					HostPort:      e.httpsPort,
+					Protocol:      "TCP",
+				},
+			}
+		}
+		if workers == 0 {
+			node.ExtraMounts = mounts
+		}
+		template.Nodes = append(template.Nodes, node)
+	}
+	for i := 0; i < workers; i++ {
+		node, err := e.kindNode(RoleWorker, i)
+		if err != nil {
+			return "", err
+		}
+		node.ExtraMounts = mounts
+		template.Nodes = append(template.Nodes, node)
+	}
 
 	yamlData, err := yaml.Marshal(&template)
 	if err != nil {
-		logger.Fatalf("error: %v", err)
+		return "", err
+	}
+	return string(yamlData), nil
+}
+
+// Kind node with kubeadm patch for labels and taints
+func (e *Environment) kindNode(role string, index int) (KindNode, error) {
+	node := KindNode{
+		Role:        role,
+		Image:       e.topology.image(),
+		ExtraMounts: []KindMount{},
+	}
+	labels, taints, err := e.topology.node(role, index)
+	if err != nil {
+		return node, err
+	}
+
+	patch := kubeadmPatch{Kind: "JoinConfiguration"}
+	if role == RoleControlPlane && index == 0 {
+		patch.Kind = "InitConfiguration"
+		labels["ingress-ready"] = "true"
+	}
+	if len(labels) == 0 && len(taints) == 0 {
+		return node, nil
+	}
+	if len(labels) > 0 {
+		patch.NodeRegistration.KubeletExtraArgs = map[string]string{
+			"node-labels": joinLabels(labels),
+		}
+	}
+	for _, taint := range taints {
+		patch.NodeRegistration.Taints = append(patch.NodeRegistration.Taints, kubeadmTaint{
+			Key:    taint.Key,
+			Value:  taint.Value,
+			Effect: string(taint.Effect),
+		})
+	}
+	patchYaml, err := yaml.Marshal(&patch)
+	if err != nil {
+		return node, err
 	}
-	return string(yamlData)
+	node.KubeadmConfigPatches = []string{string(patchYaml)}
+	return node, nil
 }
@@ -0,0 +1,118 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Environment of current kubeconfig context
+type CurrentEnvironment struct {
+	Context string `json:"context"`
+	Engine  string `json:"engine,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// Switch current context of kubeconfig, file of KUBECONFIG with current context is modified
+func SwitchContext(name string) error {
+	po := clientcmd.NewDefaultPathOptions()
+	config, err := po.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Contexts[name]; !ok {
+		return fmt.Errorf("context %s not found in kubeconfig", name)
+	}
+	config.CurrentContext = name
+	return clientcmd.ModifyConfig(po, *config, true)
+}
+
+// Use environment context as current context
+func (e *Environment) Use(logger *zap.SugaredLogger) error {
+	contextName, err := e.contextName()
+	if err != nil {
+		return err
+	}
+	err = SwitchContext(contextName)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Switched to context %s of environment %s.", contextName, e.name)
+	return nil
+}
+
+// Kubeconfig with environment context only, certificates and keys are embedded
+func (e *Environment) Kubeconfig() ([]byte, error) {
+	contextName, err := e.contextName()
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.NewDefaultPathOptions().GetStartingConfig()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := config.Contexts[contextName]; !ok {
+		return nil, fmt.Errorf("context %s of environment %s not found in kubeconfig", contextName, e.name)
+	}
+	config.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(config); err != nil {
+		return nil, err
+	}
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return nil, err
+	}
+	return clientcmd.Write(*config)
+}
+
+// Export kubeconfig of environment to file, existing file is replaced
+func (e *Environment) ExportKubeconfig(path string, logger *zap.SugaredLogger) error {
+	data, err := e.Kubeconfig()
+	if err != nil {
+		return err
+	}
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return err
+	}
+	err = clientcmd.WriteToFile(*config, path)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Kubeconfig of environment %s exported to %s.", e.name, path)
+	return nil
+}
+
+// Environment of current context, engine is detected by context name
+func Current() (*CurrentEnvironment, error) {
+	config, err := clientcmd.NewDefaultPathOptions().GetStartingConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.CurrentContext == "" {
+		return nil, fmt.Errorf("current context is not set in kubeconfig")
+	}
+	current := &CurrentEnvironment{Context: config.CurrentContext}
+	for _, p := range contextPrefixes() {
+		if p.prefix != "" && strings.HasPrefix(current.Context, p.prefix) {
+			current.Engine = p.engine
+			current.Name = strings.TrimPrefix(current.Context, p.prefix)
+			return current, nil
+		}
+	}
+	current.Name = current.Context
+	return current, nil
+}
+
+func (e *Environment) contextName() (string, error) {
+	if e.context != "" {
+		return e.context, nil
+	}
+	engine, err := e.kubernetesEngine()
+	if err != nil {
+		return "", err
+	}
+	return engine.ContextName(e), nil
+}
@@ -0,0 +1,222 @@
+package environment
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kndpio/kndp/internal/configuration"
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/provider"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	StatusUnreachable EngineStatus = "unreachable"
+
+	// Context is queried by list within timeout, when timeout isn't provided
+	DefaultListTimeout = 5 * time.Second
+)
+
+// Kubernetes engines detected by scheme of node provider ID
+var providerEngines = map[string]string{
+	"kind":  "kind",
+	"k3s":   "k3s",
+	"aws":   "eks",
+	"gce":   "gke",
+	"azure": "aks",
+}
+
+// Environment found in kubeconfig context
+type Summary struct {
+	Name              string       `json:"name"`
+	Engine            string       `json:"engine,omitempty"`
+	Status            EngineStatus `json:"status"`
+	Current           bool         `json:"current"`
+	KubernetesVersion string       `json:"kubernetesVersion,omitempty"`
+	CrossplaneVersion string       `json:"crossplaneVersion,omitempty"`
+	Providers         int          `json:"providers"`
+	Configurations    int          `json:"configurations"`
+	Message           string       `json:"message,omitempty"`
+}
+
+// List Environments in contexts of kubeconfig, contexts are queried in parallel within timeout.
+// Unreachable contexts are listed with error, reachable contexts without engine are listed without Crossplane version.
+func ListEnvironments(ctx context.Context, timeout time.Duration) ([]Summary, error) {
+	kubeconfig, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultListTimeout
+	}
+
+	summaries := make([]*Summary, 0, len(kubeconfig.Contexts))
+	var wg sync.WaitGroup
+	for name := range kubeconfig.Contexts {
+		summary := &Summary{Name: name, Current: name == kubeconfig.CurrentContext}
+		summaries = append(summaries, summary)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary.inspect(ctx, kubeconfig, timeout)
+		}()
+	}
+	wg.Wait()
+
+	environments := []Summary{}
+	for _, summary := range summaries {
+		environments = append(environments, *summary)
+	}
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].Name < environments[j].Name
+	})
+	return environments, nil
+}
+
+// Fill summary from context, reachable context without engine is running with message why engine is unknown
+func (s *Summary) inspect(parent context.Context, kubeconfig *clientcmdapi.Config, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	configClient, err := clientcmd.NewNonInteractiveClientConfig(*kubeconfig, s.Name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		s.unreachable(parent, kubeconfig, timeout, err)
+		return
+	}
+	configClient.Timeout = timeout
+
+	client, err := kube.Client(configClient)
+	if err != nil {
+		s.unreachable(parent, kubeconfig, timeout, err)
+		return
+	}
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		s.unreachable(parent, kubeconfig, timeout, err)
+		return
+	}
+
+	s.Status = StatusRunning
+	s.KubernetesVersion = version.GitVersion
+	if detected, err := ClusterEngine(ctx, client); err == nil {
+		s.Engine = detected
+	}
+
+	installer, err := engine.GetEngine(configClient)
+	if err != nil {
+		s.Message = err.Error()
+		return
+	}
+	release, err := installer.GetRelease()
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		s.Message = "engine is not installed"
+		return
+	}
+	if err != nil {
+		s.Message = err.Error()
+		return
+	}
+	s.CrossplaneVersion = engine.ReleaseVersion(release)
+
+	dynamicClient, err := dynamic.NewForConfig(configClient)
+	if err != nil {
+		s.Message = err.Error()
+		return
+	}
+	providers, err := dynamicClient.Resource(provider.ResourceId()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		s.Message = err.Error()
+		return
+	}
+	s.Providers = len(providers.Items)
+	configurations, err := dynamicClient.Resource(configuration.ResourceId()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		s.Message = err.Error()
+		return
+	}
+	s.Configurations = len(configurations.Items)
+}
+
+// Unreachable context of local cluster is reported as stopped, when engine finds stopped environment
+func (s *Summary) unreachable(parent context.Context, kubeconfig *clientcmdapi.Config, timeout time.Duration, err error) {
+	s.Status = StatusUnreachable
+	s.Message = err.Error()
+	if !localContext(kubeconfig, s.Name) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	for _, p := range contextPrefixes() {
+		if !strings.HasPrefix(s.Name, p.prefix) {
+			continue
+		}
+		e := New(p.engine, strings.TrimPrefix(s.Name, p.prefix))
+		status, err := engines[p.engine].Status(ctx, e)
+		if err == nil && status == StatusStopped {
+			s.Engine = p.engine
+			s.Status = StatusStopped
+			s.Message = ""
+			return
+		}
+	}
+}
+
+// Context with API server on local host, clusters of local engines are served there
+func localContext(kubeconfig *clientcmdapi.Config, name string) bool {
+	kubeContext, ok := kubeconfig.Contexts[name]
+	if !ok {
+		return false
+	}
+	cluster, ok := kubeconfig.Clusters[kubeContext.Cluster]
+	if !ok {
+		return false
+	}
+	server, err := url.Parse(cluster.Server)
+	if err != nil {
+		return false
+	}
+	if server.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(server.Hostname())
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// Engine of cluster detected by its nodes, empty when engine is unknown
+func ClusterEngine(ctx context.Context, client kubernetes.Interface) (string, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil || len(nodes.Items) == 0 {
+		return "", err
+	}
+	node := nodes.Items[0]
+	return nodeEngine(node.Name, node.Labels, node.Spec.ProviderID), nil
+}
+
+// Engine of cluster by node provider ID and labels, k3d nodes are k3s nodes named by k3d
+func nodeEngine(name string, labels map[string]string, providerID string) string {
+	if _, ok := labels["minikube.k8s.io/name"]; ok {
+		return "minikube"
+	}
+	scheme, _, found := strings.Cut(providerID, "://")
+	if !found {
+		return ""
+	}
+	detected := providerEngines[scheme]
+	if detected == "k3s" && strings.HasPrefix(name, "k3d-") {
+		return "k3d"
+	}
+	return detected
+}
@@ -4,3 +4,15 @@ type EnvironmentOptions struct {
 	ingressController string
 	policyController  string
 }
+
+// Ingress controller installed to environment, nginx, traefik or none
+func (e *Environment) WithIngressController(name string) *Environment {
+	e.options.ingressController = name
+	return e
+}
+
+// Policy controller installed to environment, kyverno or none
+func (e *Environment) WithPolicyController(name string) *Environment {
+	e.options.policyController = name
+	return e
+}
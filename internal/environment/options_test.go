@@ -0,0 +1,24 @@
+package environment
+
+import "testing"
+
+func TestEnvironmentOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     *Environment
+		wantErr bool
+	}{
+		{name: "no addons", env: New("kind", "dev")},
+		{name: "known addons", env: New("kind", "dev").WithIngressController("traefik").WithPolicyController("kyverno")},
+		{name: "none", env: New("kind", "dev").WithIngressController(AddonNone).WithPolicyController(AddonNone)},
+		{name: "unknown ingress", env: New("kind", "dev").WithIngressController("haproxy"), wantErr: true},
+		{name: "policy as ingress", env: New("kind", "dev").WithIngressController("kyverno"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.env.options.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
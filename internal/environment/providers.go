@@ -0,0 +1,74 @@
+package environment
+
+import (
+	"context"
+	"time"
+
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/provider"
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+const privilegedProvidersTimeout = 5 * time.Minute
+
+// Install Kubernetes and Helm providers, configured to manage environment cluster
+func (e *Environment) setupPrivilegedProviders(ctx context.Context, configClient *rest.Config, logger *zap.SugaredLogger) error {
+	packages := []string{}
+	if e.privileged.Kubernetes {
+		packages = append(packages, engine.KubernetesProviderPackage)
+	}
+	if e.privileged.Helm {
+		packages = append(packages, engine.HelmProviderPackage)
+	}
+
+	logger.Info("Installing privileged providers")
+	err := provider.New("").ApplyProvider(ctx, packages, configClient, logger)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(configClient)
+	if err != nil {
+		return err
+	}
+	err = provider.HealthCheck(ctx, dynamicClient, packages, time.After(privilegedProvidersTimeout), logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Configuring privileged providers")
+	if e.privileged.ClusterAdmin {
+		logger.Warn("Providers are granted all permissions in cluster.")
+	}
+	err = engine.SetupPrivilegedKubernetesProvider(ctx, configClient, engine.ProviderSetup{
+		Kubernetes:   e.privileged.Kubernetes,
+		Helm:         e.privileged.Helm,
+		ClusterAdmin: e.privileged.ClusterAdmin,
+		Timeout:      privilegedProvidersTimeout,
+	}, logger)
+	if err != nil {
+		return err
+	}
+
+	client, err := kube.Client(configClient)
+	if err != nil {
+		return err
+	}
+	checks := []Check{}
+	if e.privileged.Kubernetes {
+		checks = append(checks, providerConfigCheck(ctx, client, dynamicClient, engine.KubernetesProviderConfigResource, engine.ProviderConfigName))
+	}
+	if e.privileged.Helm {
+		checks = append(checks, providerConfigCheck(ctx, client, dynamicClient, engine.HelmProviderConfigResource, engine.HelmProviderConfigName))
+	}
+	for _, check := range checks {
+		if check.Healthy && check.Status == "Configured" {
+			logger.Infof("ProviderConfig %s is ready to use.", check.Name)
+		} else {
+			logger.Warnf("ProviderConfig %s is not usable: %s", check.Name, check.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package environment
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/kndpio/kndp/internal/configuration"
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/namespace"
+	"github.com/kndpio/kndp/internal/provider"
+	"github.com/kndpio/kndp/internal/registry"
+	"github.com/kndpio/kndp/internal/resources"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	SnapshotKind = "EnvironmentSnapshot"
+
+	snapshotManifestFile       = "manifest.yaml"
+	snapshotValuesFile         = "engine/values.yaml"
+	snapshotRegistriesFile     = "registries.yaml"
+	snapshotProvidersFile      = "providers.yaml"
+	snapshotConfigurationsFile = "configurations.yaml"
+	snapshotCompositesFile     = "composites.yaml"
+
+	snapshotResourceTimeout  = 5 * time.Minute
+	snapshotResourceInterval = 5 * time.Second
+)
+
+// Manifest of environment snapshot archive
+type SnapshotManifest struct {
+	APIVersion    string    `json:"apiVersion"`
+	Kind          string    `json:"kind"`
+	Context       string    `json:"context"`
+	CreatedAt     time.Time `json:"createdAt"`
+	EngineVersion string    `json:"engineVersion,omitempty"`
+	Redacted      bool      `json:"redacted,omitempty"`
+}
+
+// Snapshot of environment engine, registries, packages and composites
+type Snapshot struct {
+	Manifest       SnapshotManifest
+	Values         map[string]any
+	Registries     []corev1.Secret
+	Providers      []unstructured.Unstructured
+	Configurations []unstructured.Unstructured
+	Composites     []resources.Composite
+}
+
+// Export environment from context to archive, registry credentials are removed when redacted
+func ExportEnvironment(ctx context.Context, contextName string, path string, redact bool, logger *zap.SugaredLogger) error {
+	configClient, err := kube.Config(contextName)
+	if err != nil {
+		return err
+	}
+	client, err := kube.Client(configClient)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(configClient)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &Snapshot{
+		Manifest: SnapshotManifest{
+			APIVersion: SpecAPIVersion,
+			Kind:       SnapshotKind,
+			Context:    contextName,
+			CreatedAt:  time.Now().UTC(),
+			Redacted:   redact,
+		},
+	}
+
+	logger.Info("Exporting engine values")
+	installer, err := engine.GetEngine(configClient)
+	if err != nil {
+		return err
+	}
+	rel, err := installer.GetRelease()
+	if err != nil {
+		return fmt.Errorf("engine release not found in context %s: %v", contextName, err)
+	}
+	snapshot.Values = rel.Config
+	snapshot.Manifest.EngineVersion = engine.ReleaseVersion(rel)
+
+	logger.Info("Exporting registries")
+	registries, err := registry.Registries(ctx, client)
+	if err != nil {
+		return err
+	}
+	for _, reg := range registries {
+		secret := reg.ToSecret()
+		secret.ObjectMeta = metav1.ObjectMeta{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		}
+		if redact {
+			secret.Data = nil
+			secret.StringData = nil
+		}
+		snapshot.Registries = append(snapshot.Registries, *secret)
+	}
+
+	logger.Info("Exporting providers and configurations")
+	snapshot.Providers, err = exportObjects(ctx, dynamicClient, provider.ResourceId())
+	if err != nil {
+		return err
+	}
+	snapshot.Configurations, err = exportObjects(ctx, dynamicClient, configuration.ResourceId())
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Exporting composite resources")
+	snapshot.Composites, err = resources.ManagedComposites(ctx, dynamicClient)
+	if err != nil {
+		return err
+	}
+	for i := range snapshot.Composites {
+		resources.CleanObject(&snapshot.Composites[i].Object)
+	}
+
+	if err := snapshot.Write(path); err != nil {
+		return err
+	}
+	logger.Infof("Environment exported to %s: %d registries, %d providers, %d configurations, %d composites",
+		path, len(snapshot.Registries), len(snapshot.Providers), len(snapshot.Configurations), len(snapshot.Composites))
+	if redact && len(snapshot.Registries) > 0 {
+		logger.Warn("Registry credentials are redacted, registries should be recreated after import.")
+	}
+	return nil
+}
+
+// Import environment from archive to context
+func ImportEnvironment(ctx context.Context, path string, contextName string, logger *zap.SugaredLogger) error {
+	snapshot, err := ReadSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	configClient, err := kube.Config(contextName)
+	if err != nil {
+		return err
+	}
+	client, err := kube.Client(configClient)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(configClient)
+	if err != nil {
+		return err
+	}
+
+	err = namespace.CreateNamespace(ctx, configClient)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Importing registries")
+	for _, secret := range snapshot.Registries {
+		if len(secret.Data) == 0 {
+			logger.Warnf("Registry %s has redacted credentials, skipped.", secret.Annotations[registry.RegistryServerLabel])
+			continue
+		}
+		_, err := client.CoreV1().Secrets(namespace.Namespace).Create(ctx, &secret, metav1.CreateOptions{})
+		if kerrors.IsAlreadyExists(err) {
+			logger.Warnf("Registry %s already exists, skipped.", secret.Annotations[registry.RegistryServerLabel])
+		} else if err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Importing engine")
+	err = engine.InstallEngine(ctx, configClient, snapshot.Manifest.EngineVersion, snapshot.Values, logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Importing providers and configurations")
+	err = importObjects(ctx, dynamicClient, provider.ResourceId(), snapshot.Providers, logger)
+	if err != nil {
+		return err
+	}
+	err = importObjects(ctx, dynamicClient, configuration.ResourceId(), snapshot.Configurations, logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Importing composite resources")
+	for _, composite := range snapshot.Composites {
+		err := waitForResource(ctx, dynamicClient, composite.ResourceId(), logger)
+		if err != nil {
+			return err
+		}
+		err = importObjects(ctx, dynamicClient, composite.ResourceId(), []unstructured.Unstructured{composite.Object}, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("Environment imported from %s to context %s.", path, contextName)
+	return nil
+}
+
+func exportObjects(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		resources.CleanObject(&list.Items[i])
+	}
+	return list.Items, nil
+}
+
+// Create cluster scoped objects, existing ones are skipped
+func importObjects(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, objects []unstructured.Unstructured, logger *zap.SugaredLogger) error {
+	for _, object := range objects {
+		_, err := dynamicClient.Resource(gvr).Create(ctx, &object, metav1.CreateOptions{})
+		if kerrors.IsAlreadyExists(err) {
+			logger.Warnf("%s %s already exists, skipped.", object.GetKind(), object.GetName())
+		} else if err != nil {
+			return fmt.Errorf("failed to create %s %s: %v", object.GetKind(), object.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// Wait until resource is served, composite resources are defined by configurations asynchronously
+func waitForResource(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, logger *zap.SugaredLogger) error {
+	ctx, cancel := context.WithTimeout(ctx, snapshotResourceTimeout)
+	defer cancel()
+	for {
+		_, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+		if err == nil {
+			return nil
+		}
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+		logger.Debugf("Waiting for resource %s to be served", gvr.String())
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("resource %s is not served: %v", gvr.String(), ctx.Err())
+		case <-time.After(snapshotResourceInterval):
+		}
+	}
+}
+
+// Write snapshot to gzipped tar archive
+func (s *Snapshot) Write(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	entries := []struct {
+		name  string
+		value any
+	}{
+		{snapshotManifestFile, s.Manifest},
+		{snapshotValuesFile, s.Values},
+		{snapshotRegistriesFile, s.Registries},
+		{snapshotProvidersFile, s.Providers},
+		{snapshotConfigurationsFile, s.Configurations},
+		{snapshotCompositesFile, s.Composites},
+	}
+	for _, entry := range entries {
+		data, err := yaml.Marshal(entry.value)
+		if err != nil {
+			return err
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    entry.name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: s.Manifest.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read snapshot from gzipped tar archive
+func ReadSnapshot(path string) (*Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment archive %s: %v", path, err)
+	}
+	defer gz.Close()
+
+	s := &Snapshot{}
+	targets := map[string]any{
+		snapshotManifestFile:       &s.Manifest,
+		snapshotValuesFile:         &s.Values,
+		snapshotRegistriesFile:     &s.Registries,
+		snapshotProvidersFile:      &s.Providers,
+		snapshotConfigurationsFile: &s.Configurations,
+		snapshotCompositesFile:     &s.Composites,
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read environment archive %s: %v", path, err)
+		}
+		target, ok := targets[header.Name]
+		if !ok {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, target); err != nil {
+			return nil, fmt.Errorf("failed to parse %s from environment archive: %v", header.Name, err)
+		}
+	}
+
+	if s.Manifest.Kind != SnapshotKind || s.Manifest.APIVersion != SpecAPIVersion {
+		return nil, fmt.Errorf("%s is not an environment archive", path)
+	}
+	return s, nil
+}
@@ -0,0 +1,156 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SpecAPIVersion = "kndp.io/v1alpha1"
+	SpecKind       = "EnvironmentConfig"
+)
+
+// Spec is a declarative, versioned description of an Environment
+type Spec struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   SpecMetadata    `yaml:"metadata"`
+	Spec       EnvironmentSpec `yaml:"spec"`
+}
+
+type SpecMetadata struct {
+	Name string `yaml:"name"`
+}
+
+type EnvironmentSpec struct {
+	Engine         string         `yaml:"engine,omitempty"`
+	Context        string         `yaml:"context,omitempty"`
+	Ports          PortsSpec      `yaml:"ports,omitempty"`
+	Mounts         []Mount        `yaml:"mounts,omitempty"`
+	Topology       Topology       `yaml:"topology,omitempty"`
+	Crossplane     CrossplaneSpec `yaml:"crossplane,omitempty"`
+	Providers      []string       `yaml:"providers,omitempty"`
+	Configurations []string       `yaml:"configurations,omitempty"`
+	Registries     []RegistrySpec `yaml:"registries,omitempty"`
+	Ingress        string         `yaml:"ingress,omitempty"`
+	Policy         string         `yaml:"policy,omitempty"`
+
+	PrivilegedProviders PrivilegedProvidersSpec `yaml:"privilegedProviders,omitempty"`
+}
+
+type PortsSpec struct {
+	Http  int `yaml:"http,omitempty"`
+	Https int `yaml:"https,omitempty"`
+}
+
+type Mount struct {
+	HostPath      string `yaml:"hostPath"`
+	ContainerPath string `yaml:"containerPath"`
+}
+
+type CrossplaneSpec struct {
+	Version string         `yaml:"version,omitempty"`
+	Values  map[string]any `yaml:"values,omitempty"`
+}
+
+// Providers managing resources of environment cluster itself,
+// by default they have permissions of Crossplane admin aggregated role.
+type PrivilegedProvidersSpec struct {
+	Kubernetes   bool `yaml:"kubernetes,omitempty"`
+	Helm         bool `yaml:"helm,omitempty"`
+	ClusterAdmin bool `yaml:"clusterAdmin,omitempty"`
+}
+
+// Registry credentials, values are expanded from environment variables,
+// so secrets could be referenced as ${VARIABLE} instead of stored in file.
+type RegistrySpec struct {
+	Server       string   `yaml:"server"`
+	Servers      []string `yaml:"servers,omitempty"`
+	Username     string   `yaml:"username,omitempty"`
+	Password     string   `yaml:"password,omitempty"`
+	Token        string   `yaml:"token,omitempty"`
+	Email        string   `yaml:"email,omitempty"`
+	DockerConfig bool     `yaml:"dockerConfig,omitempty"`
+	Default      bool     `yaml:"default,omitempty"`
+	Local        bool     `yaml:"local,omitempty"`
+	Storage      string   `yaml:"storage,omitempty"`
+	StorageSize  string   `yaml:"storageSize,omitempty"`
+	TLS          bool     `yaml:"tls,omitempty"`
+}
+
+// Load Environment spec from YAML file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &Spec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse environment spec %s: %v", path, err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	for i, reg := range spec.Spec.Registries {
+		spec.Spec.Registries[i].Username = os.ExpandEnv(reg.Username)
+		spec.Spec.Registries[i].Password = os.ExpandEnv(reg.Password)
+		spec.Spec.Registries[i].Token = os.ExpandEnv(reg.Token)
+		spec.Spec.Registries[i].Email = os.ExpandEnv(reg.Email)
+	}
+	return spec, nil
+}
+
+// Validate version and kind of spec
+func (s *Spec) Validate() error {
+	if s.APIVersion != SpecAPIVersion {
+		return fmt.Errorf("unsupported environment spec apiVersion '%s', expected '%s'", s.APIVersion, SpecAPIVersion)
+	}
+	if s.Kind != SpecKind {
+		return fmt.Errorf("unsupported environment spec kind '%s', expected '%s'", s.Kind, SpecKind)
+	}
+	for _, mount := range s.Spec.Mounts {
+		if mount.HostPath == "" || mount.ContainerPath == "" {
+			return fmt.Errorf("environment spec mounts require hostPath and containerPath")
+		}
+	}
+	if err := s.Spec.Topology.Validate(); err != nil {
+		return err
+	}
+	for _, reg := range s.Spec.Registries {
+		if reg.Server == "" && !reg.Local {
+			return fmt.Errorf("environment spec registries require server")
+		}
+	}
+	return nil
+}
+
+// Environment entity described by spec
+func (s *Spec) Environment() *Environment {
+	engine := s.Spec.Engine
+	if engine == "" {
+		engine = "kind"
+	}
+	e := New(engine, s.Metadata.Name).
+		WithContext(s.Spec.Context).
+		WithHttpPort(80).
+		WithHttpsPort(443).
+		WithMounts(s.Spec.Mounts...).
+		WithTopology(s.Spec.Topology).
+		WithEngineVersion(s.Spec.Crossplane.Version).
+		WithValues(s.Spec.Crossplane.Values).
+		WithProviders(s.Spec.Providers...).
+		WithConfigurations(s.Spec.Configurations...).
+		WithRegistries(s.Spec.Registries...).
+		WithPrivilegedProviders(s.Spec.PrivilegedProviders).
+		WithIngressController(s.Spec.Ingress).
+		WithPolicyController(s.Spec.Policy)
+	if s.Spec.Ports.Http != 0 {
+		e.WithHttpPort(s.Spec.Ports.Http)
+	}
+	if s.Spec.Ports.Https != 0 {
+		e.WithHttpsPort(s.Spec.Ports.Https)
+	}
+	return e
+}
@@ -0,0 +1,129 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const stateFileName = "state.yaml"
+
+// Local state of environments created by engines on this host
+type State struct {
+	Environments []StateEntry `yaml:"environments"`
+}
+
+// Environment recorded on create, expired environment is deleted by garbage collection
+type StateEntry struct {
+	Name      string     `yaml:"name"`
+	Engine    string     `yaml:"engine"`
+	Context   string     `yaml:"context"`
+	MountPath string     `yaml:"mountPath,omitempty"`
+	CreatedAt time.Time  `yaml:"createdAt"`
+	ExpiresAt *time.Time `yaml:"expiresAt,omitempty"`
+}
+
+// Path of state file in user config directory
+func StatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kndp", stateFileName), nil
+}
+
+// Load state, state is empty if file doesn't exist
+func LoadState() (*State, error) {
+	path, err := StatePath()
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse environments state %s: %v", path, err)
+	}
+	return state, nil
+}
+
+// Save state to file
+func (s *State) Save() error {
+	path, err := StatePath()
+	if err != nil {
+		return err
+	}
+	sort.Slice(s.Environments, func(i, j int) bool {
+		return s.Environments[i].Engine+"/"+s.Environments[i].Name < s.Environments[j].Engine+"/"+s.Environments[j].Name
+	})
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Add entry to state, entry of the same environment is replaced
+func (s *State) Add(entry StateEntry) {
+	s.Remove(entry.Engine, entry.Name)
+	s.Environments = append(s.Environments, entry)
+}
+
+// Remove entry of environment from state
+func (s *State) Remove(engine string, name string) {
+	entries := []StateEntry{}
+	for _, entry := range s.Environments {
+		if entry.Engine != engine || entry.Name != name {
+			entries = append(entries, entry)
+		}
+	}
+	s.Environments = entries
+}
+
+// Entry is expired when its expiry is reached
+func (entry StateEntry) Expired(now time.Time) bool {
+	return entry.ExpiresAt != nil && !now.Before(*entry.ExpiresAt)
+}
+
+// Record created environment with expiry of ttl, no expiry when ttl is zero
+func (e *Environment) recordState() error {
+	state, err := LoadState()
+	if err != nil {
+		return err
+	}
+	entry := StateEntry{
+		Name:      e.name,
+		Engine:    e.engine,
+		Context:   e.context,
+		MountPath: e.mountPath,
+		CreatedAt: time.Now().UTC(),
+	}
+	if e.ttl > 0 {
+		expiresAt := entry.CreatedAt.Add(e.ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+	state.Add(entry)
+	return state.Save()
+}
+
+// Remove deleted environment from state
+func (e *Environment) forgetState() error {
+	state, err := LoadState()
+	if err != nil {
+		return err
+	}
+	state.Remove(e.engine, e.name)
+	return state.Save()
+}
@@ -0,0 +1,135 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Use temporary user config directory for state of test
+func tempState(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, ".config"))
+	path, err := StatePath()
+	if err != nil {
+		t.Fatalf("StatePath() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadStateMissing(t *testing.T) {
+	tempState(t)
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if len(state.Environments) != 0 {
+		t.Errorf("LoadState() = %v, want empty state", state.Environments)
+	}
+}
+
+func TestLoadStateInvalid(t *testing.T) {
+	path := tempState(t)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("environments: {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(); err == nil {
+		t.Error("LoadState() of invalid file succeeded, want error")
+	}
+}
+
+func TestStateSave(t *testing.T) {
+	tempState(t)
+	expiresAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	state := &State{}
+	state.Add(StateEntry{Name: "dev", Engine: "kind", Context: "kind-dev"})
+	state.Add(StateEntry{Name: "ci", Engine: "k3s", Context: "ci", MountPath: "/tmp/ci", ExpiresAt: &expiresAt})
+	state.Add(StateEntry{Name: "dev", Engine: "k3d", Context: "k3d-dev"})
+	// Entry of the same environment is replaced
+	state.Add(StateEntry{Name: "dev", Engine: "kind", Context: "kind-dev-2"})
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadState()
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	want := []string{"k3d/dev/k3d-dev", "k3s/ci/ci", "kind/dev/kind-dev-2"}
+	if len(loaded.Environments) != len(want) {
+		t.Fatalf("LoadState() = %v, want %v", loaded.Environments, want)
+	}
+	for i, entry := range loaded.Environments {
+		if got := entry.Engine + "/" + entry.Name + "/" + entry.Context; got != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, got, want[i])
+		}
+	}
+	ci := loaded.Environments[1]
+	if ci.MountPath != "/tmp/ci" || ci.ExpiresAt == nil || !ci.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("entry ci = %+v, want mount path and expiry kept", ci)
+	}
+
+	loaded.Remove("kind", "dev")
+	loaded.Remove("kind", "missing")
+	if len(loaded.Environments) != 2 {
+		t.Errorf("Remove() left %v, want 2 entries", loaded.Environments)
+	}
+}
+
+func TestStateEntryExpired(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      bool
+	}{
+		{name: "no expiry", expiresAt: nil, want: false},
+		{name: "expired", expiresAt: &past, want: true},
+		{name: "expires now", expiresAt: &now, want: true},
+		{name: "not expired", expiresAt: &future, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (StateEntry{ExpiresAt: tt.expiresAt}).Expired(now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordState(t *testing.T) {
+	tempState(t)
+	e := New("kind", "dev").WithMountPath("/tmp/dev").WithTTL(time.Hour)
+	e.context = "kind-dev"
+	if err := e.recordState(); err != nil {
+		t.Fatalf("recordState() error = %v", err)
+	}
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if len(state.Environments) != 1 {
+		t.Fatalf("LoadState() = %v, want 1 entry", state.Environments)
+	}
+	entry := state.Environments[0]
+	if entry.Context != "kind-dev" || entry.MountPath != "/tmp/dev" {
+		t.Errorf("entry = %+v, want context and mount path recorded", entry)
+	}
+	if entry.ExpiresAt == nil || entry.ExpiresAt.Sub(entry.CreatedAt) != time.Hour {
+		t.Errorf("entry expiry = %v, want created at %v with ttl", entry.ExpiresAt, entry.CreatedAt)
+	}
+
+	if err := e.forgetState(); err != nil {
+		t.Fatalf("forgetState() error = %v", err)
+	}
+	if state, _ := LoadState(); len(state.Environments) != 0 {
+		t.Errorf("LoadState() after forgetState() = %v, want empty state", state.Environments)
+	}
+}
@@ -0,0 +1,265 @@
+package environment
+
+import (
+	"context"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/kndpio/kndp/internal/configuration"
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/namespace"
+	"github.com/kndpio/kndp/internal/provider"
+	"github.com/kndpio/kndp/internal/registry"
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	CheckNode           = "Node"
+	CheckEngine         = "Engine"
+	CheckProvider       = "Provider"
+	CheckConfiguration  = "Configuration"
+	CheckRegistry       = "Registry"
+	CheckLocalRegistry  = "LocalRegistry"
+	CheckProviderConfig = "ProviderConfig"
+
+	statusNotInstalled = "NotInstalled"
+)
+
+// Health report of environment
+type Health struct {
+	Environment string  `json:"environment"`
+	Context     string  `json:"context"`
+	Healthy     bool    `json:"healthy"`
+	Checks      []Check `json:"checks"`
+}
+
+// Health check of environment component
+type Check struct {
+	Kind    string         `json:"kind"`
+	Name    string         `json:"name"`
+	Status  string         `json:"status"`
+	Healthy bool           `json:"healthy"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Health of environment nodes, engine, packages and registries
+func (e *Environment) Health(ctx context.Context, logger *zap.SugaredLogger) (*Health, error) {
+	configClient, err := e.contextConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kube.Client(configClient)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(configClient)
+	if err != nil {
+		return nil, err
+	}
+
+	health := &Health{
+		Environment: e.name,
+		Context:     e.context,
+	}
+
+	nodes, err := nodeChecks(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	health.Checks = append(health.Checks, nodes...)
+
+	engineStatus, rel := engineCheck(configClient)
+	health.Checks = append(health.Checks, engineStatus)
+
+	for _, p := range provider.ListProviders(ctx, dynamicClient, logger) {
+		health.Checks = append(health.Checks, packageCheck(CheckProvider, p.Name, p.Spec.Package, p.GetCondition(crossv1.TypeInstalled), p.GetCondition(crossv1.TypeHealthy)))
+	}
+	for _, c := range configuration.GetConfigurations(ctx, dynamicClient) {
+		health.Checks = append(health.Checks, packageCheck(CheckConfiguration, c.Name, c.Spec.Package, c.GetCondition(crossv1.TypeInstalled), c.GetCondition(crossv1.TypeHealthy)))
+	}
+
+	registries, err := registryChecks(ctx, client, rel)
+	if err != nil {
+		return nil, err
+	}
+	health.Checks = append(health.Checks, registries...)
+
+	localRegistry, err := localRegistryCheck(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	health.Checks = append(health.Checks, localRegistry)
+	health.Checks = append(health.Checks, addonChecks(configClient)...)
+
+	health.Checks = append(health.Checks,
+		providerConfigCheck(ctx, client, dynamicClient, engine.KubernetesProviderConfigResource, engine.ProviderConfigName),
+		providerConfigCheck(ctx, client, dynamicClient, engine.HelmProviderConfigResource, engine.HelmProviderConfigName),
+	)
+
+	health.Healthy = true
+	for _, check := range health.Checks {
+		if !check.Healthy {
+			health.Healthy = false
+		}
+	}
+	return health, nil
+}
+
+func nodeChecks(ctx context.Context, client kubernetes.Interface) ([]Check, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	checks := []Check{}
+	for _, node := range nodes.Items {
+		check := Check{Kind: CheckNode, Name: node.Name, Status: "NotReady"}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				check.Healthy = condition.Status == corev1.ConditionTrue
+				check.Message = condition.Message
+				if check.Healthy {
+					check.Status = "Ready"
+				}
+			}
+		}
+		check.Details = map[string]any{
+			"kubeletVersion": node.Status.NodeInfo.KubeletVersion,
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func engineCheck(configClient *rest.Config) (Check, *release.Release) {
+	check := Check{Kind: CheckEngine, Name: engine.ReleaseName, Status: statusNotInstalled}
+	installer, err := engine.GetEngine(configClient)
+	if err != nil {
+		check.Message = err.Error()
+		return check, nil
+	}
+	rel, err := installer.GetRelease()
+	if err != nil {
+		check.Message = err.Error()
+		return check, nil
+	}
+	check.Status = string(rel.Info.Status)
+	check.Healthy = rel.Info.Status == release.StatusDeployed
+	check.Message = rel.Info.Description
+	check.Details = map[string]any{
+		"version": engine.ReleaseVersion(rel),
+		"values":  rel.Config,
+	}
+	return check, rel
+}
+
+func packageCheck(kind string, name string, pkg string, installed xpv1.Condition, healthy xpv1.Condition) Check {
+	check := Check{
+		Kind:    kind,
+		Name:    name,
+		Status:  "Unhealthy",
+		Healthy: installed.Status == corev1.ConditionTrue && healthy.Status == corev1.ConditionTrue,
+		Details: map[string]any{
+			"package": pkg,
+		},
+	}
+	if check.Healthy {
+		check.Status = "Healthy"
+	} else if installed.Status != corev1.ConditionTrue {
+		check.Status = "NotInstalled"
+		check.Message = installed.Message
+	} else {
+		check.Message = healthy.Message
+	}
+	return check
+}
+
+// Registries are healthy when assigned to engine image pull secrets
+func registryChecks(ctx context.Context, client *kubernetes.Clientset, rel *release.Release) ([]Check, error) {
+	registries, err := registry.Registries(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	pullSecrets := map[string]bool{}
+	if rel != nil && rel.Config != nil {
+		if secrets, ok := rel.Config["imagePullSecrets"].([]interface{}); ok {
+			for _, secret := range secrets {
+				if name, ok := secret.(string); ok {
+					pullSecrets[name] = true
+				}
+			}
+		}
+	}
+	checks := []Check{}
+	for _, reg := range registries {
+		check := Check{
+			Kind:    CheckRegistry,
+			Name:    reg.Name,
+			Status:  "Assigned",
+			Healthy: pullSecrets[reg.Name],
+			Details: map[string]any{
+				"server": reg.Annotations[registry.RegistryServerLabel],
+			},
+		}
+		if !check.Healthy {
+			check.Status = "NotAssigned"
+			check.Message = "registry secret is not assigned to engine image pull secrets"
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// Local registry is optional, so it is healthy when not installed
+func localRegistryCheck(ctx context.Context, client *kubernetes.Clientset) (Check, error) {
+	check := Check{Kind: CheckLocalRegistry, Name: registry.LocalServiceName, Status: statusNotInstalled, Healthy: true}
+	pod, err := registry.LocalRegistryPod(ctx, client)
+	if err != nil || pod == nil {
+		return check, err
+	}
+	check.Name = pod.Name
+	check.Status = string(pod.Status.Phase)
+	check.Healthy = false
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			check.Healthy = condition.Status == corev1.ConditionTrue
+			check.Message = condition.Message
+		}
+	}
+	return check, nil
+}
+
+// Provider configs are optional, so they are healthy when not configured,
+// configured ones are usable when secret has kubeconfig reconciled.
+func providerConfigCheck(ctx context.Context, client *kubernetes.Clientset, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, name string) Check {
+	check := Check{Kind: CheckProviderConfig, Name: name, Status: "NotConfigured", Healthy: true}
+	_, err := dynamicClient.Resource(gvr).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			check.Healthy = false
+			check.Status = "Unknown"
+			check.Message = err.Error()
+		}
+		return check
+	}
+	check.Status = "Configured"
+
+	secret, err := client.CoreV1().Secrets(namespace.Namespace).Get(ctx, engine.ProviderConfigName, metav1.GetOptions{})
+	if err != nil {
+		check.Healthy = false
+		check.Message = err.Error()
+	} else if len(secret.Data["kubeconfig"]) == 0 {
+		check.Healthy = false
+		check.Message = "kubeconfig is not reconciled in secret " + secret.Name
+	}
+	return check
+}
@@ -0,0 +1,98 @@
+package environment
+
+import (
+	"context"
+	"testing"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPackageCheck(t *testing.T) {
+	condition := func(status corev1.ConditionStatus, message string) xpv1.Condition {
+		return xpv1.Condition{Status: status, Message: message}
+	}
+	tests := []struct {
+		name        string
+		installed   xpv1.Condition
+		healthy     xpv1.Condition
+		wantStatus  string
+		wantHealthy bool
+		wantMessage string
+	}{
+		{name: "healthy", installed: condition(corev1.ConditionTrue, ""), healthy: condition(corev1.ConditionTrue, ""), wantStatus: "Healthy", wantHealthy: true},
+		{name: "not installed", installed: condition(corev1.ConditionFalse, "pull failed"), healthy: condition(corev1.ConditionUnknown, ""), wantStatus: "NotInstalled", wantMessage: "pull failed"},
+		{name: "unhealthy", installed: condition(corev1.ConditionTrue, ""), healthy: condition(corev1.ConditionFalse, "crd conflict"), wantStatus: "Unhealthy", wantMessage: "crd conflict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := packageCheck(CheckProvider, "provider-aws", "xpkg.upbound.io/upbound/provider-aws:v1", tt.installed, tt.healthy)
+			if check.Status != tt.wantStatus || check.Healthy != tt.wantHealthy || check.Message != tt.wantMessage {
+				t.Errorf("packageCheck() = %+v, want status %s, healthy %v, message %q", check, tt.wantStatus, tt.wantHealthy, tt.wantMessage)
+			}
+			if check.Details["package"] != "xpkg.upbound.io/upbound/provider-aws:v1" {
+				t.Errorf("packageCheck() details = %v, want package", check.Details)
+			}
+		})
+	}
+}
+
+func TestNodeChecks(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Message: "kubelet is posting ready status"}},
+				NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: "v1.29.1"},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Message: "network plugin not ready"}},
+			},
+		},
+	)
+	checks, err := nodeChecks(context.Background(), client)
+	if err != nil {
+		t.Fatalf("nodeChecks() error = %v", err)
+	}
+	got := map[string]Check{}
+	for _, check := range checks {
+		got[check.Name] = check
+	}
+	if check := got["ready"]; check.Status != "Ready" || !check.Healthy || check.Details["kubeletVersion"] != "v1.29.1" {
+		t.Errorf("check of ready node = %+v", check)
+	}
+	if check := got["not-ready"]; check.Status != "NotReady" || check.Healthy || check.Message != "network plugin not ready" {
+		t.Errorf("check of not ready node = %+v", check)
+	}
+}
+
+func TestClusterEngine(t *testing.T) {
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want string
+	}{
+		{name: "kind", node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "dev-control-plane"}, Spec: corev1.NodeSpec{ProviderID: "kind://docker/dev/dev-control-plane"}}, want: "kind"},
+		{name: "k3s", node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "host"}, Spec: corev1.NodeSpec{ProviderID: "k3s://host"}}, want: "k3s"},
+		{name: "k3d", node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "k3d-dev-server-0"}, Spec: corev1.NodeSpec{ProviderID: "k3s://k3d-dev-server-0"}}, want: "k3d"},
+		{name: "minikube", node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "minikube", Labels: map[string]string{"minikube.k8s.io/name": "minikube"}}}, want: "minikube"},
+		{name: "eks", node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-0-1"}, Spec: corev1.NodeSpec{ProviderID: "aws:///eu-west-1a/i-0123"}}, want: "eks"},
+		{name: "unknown", node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClusterEngine(context.Background(), fake.NewSimpleClientset(tt.node))
+			if err != nil {
+				t.Fatalf("ClusterEngine() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ClusterEngine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package environment
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	RoleControlPlane = "control-plane"
+	RoleWorker       = "worker"
+	kindNodeImage    = "kindest/node"
+)
+
+// Nodes topology of environment cluster
+type Topology struct {
+	ControlPlanes     int        `yaml:"controlPlanes,omitempty"`
+	Workers           *int       `yaml:"workers,omitempty"`
+	Image             string     `yaml:"image,omitempty"`
+	KubernetesVersion string     `yaml:"kubernetesVersion,omitempty"`
+	Nodes             []NodeSpec `yaml:"nodes,omitempty"`
+}
+
+// Labels and taints of nodes with role, all of them or only one by index
+type NodeSpec struct {
+	Role   string            `yaml:"role"`
+	Index  *int              `yaml:"index,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+	Taints []string          `yaml:"taints,omitempty"`
+}
+
+// Number of control plane nodes, at least one
+func (t Topology) controlPlanes() int {
+	if t.ControlPlanes < 1 {
+		return 1
+	}
+	return t.ControlPlanes
+}
+
+// Number of worker nodes, one by default
+func (t Topology) workers() int {
+	if t.Workers == nil {
+		return 1
+	}
+	return *t.Workers
+}
+
+// Node image requested directly or by Kubernetes version
+func (t Topology) image() string {
+	if t.Image != "" {
+		return t.Image
+	}
+	if t.KubernetesVersion != "" {
+		return kindNodeImage + ":v" + strings.TrimPrefix(t.KubernetesVersion, "v")
+	}
+	return ""
+}
+
+// Labels and taints for node with role and index
+func (t Topology) node(role string, index int) (map[string]string, []corev1.Taint, error) {
+	labels := map[string]string{}
+	taints := []corev1.Taint{}
+	for _, node := range t.Nodes {
+		if node.Role != role || (node.Index != nil && *node.Index != index) {
+			continue
+		}
+		for k, v := range node.Labels {
+			labels[k] = v
+		}
+		for _, t := range node.Taints {
+			taint, err := parseTaint(t)
+			if err != nil {
+				return nil, nil, err
+			}
+			taints = append(taints, taint)
+		}
+	}
+	return labels, taints, nil
+}
+
+// Validate roles, indexes and taints of nodes
+func (t Topology) Validate() error {
+	counts := map[string]int{
+		RoleControlPlane: t.controlPlanes(),
+		RoleWorker:       t.workers(),
+	}
+	if t.workers() < 0 {
+		return fmt.Errorf("number of workers could not be negative")
+	}
+	for _, node := range t.Nodes {
+		count, ok := counts[node.Role]
+		if !ok {
+			return fmt.Errorf("unknown node role '%s', expected %s or %s", node.Role, RoleControlPlane, RoleWorker)
+		}
+		if node.Index != nil && (*node.Index < 0 || *node.Index >= count) {
+			return fmt.Errorf("node %s.%d is out of range, environment has %d %s node(s)", node.Role, *node.Index, count, node.Role)
+		}
+		for _, t := range node.Taints {
+			if _, err := parseTaint(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Parse node label in format "<role>[.<index>]:<key>=<value>"
+func ParseNodeLabel(s string) (NodeSpec, error) {
+	node, label, err := parseNodeSelector(s)
+	if err != nil {
+		return node, err
+	}
+	key, value, ok := strings.Cut(label, "=")
+	if !ok || key == "" {
+		return node, fmt.Errorf("node label '%s' should be in format <role>[.<index>]:<key>=<value>", s)
+	}
+	node.Labels = map[string]string{key: value}
+	return node, nil
+}
+
+// Parse node taint in format "<role>[.<index>]:<key>[=<value>]:<effect>"
+func ParseNodeTaint(s string) (NodeSpec, error) {
+	node, taint, err := parseNodeSelector(s)
+	if err != nil {
+		return node, err
+	}
+	if _, err := parseTaint(taint); err != nil {
+		return node, err
+	}
+	node.Taints = []string{taint}
+	return node, nil
+}
+
+func parseNodeSelector(s string) (NodeSpec, string, error) {
+	node := NodeSpec{}
+	selector, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return node, "", fmt.Errorf("node selector is missing in '%s', expected <role>[.<index>]:", s)
+	}
+	role, index, indexed := strings.Cut(selector, ".")
+	node.Role = role
+	if indexed {
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return node, "", fmt.Errorf("node index '%s' is not a number", index)
+		}
+		node.Index = &i
+	}
+	return node, value, nil
+}
+
+func parseTaint(s string) (corev1.Taint, error) {
+	taint := corev1.Taint{}
+	keyValue, effect, ok := strings.Cut(s, ":")
+	if !ok {
+		return taint, fmt.Errorf("node taint '%s' should be in format <key>[=<value>]:<effect>", s)
+	}
+	taint.Key, taint.Value, _ = strings.Cut(keyValue, "=")
+	taint.Effect = corev1.TaintEffect(effect)
+	switch taint.Effect {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return taint, fmt.Errorf("node taint effect '%s' is not supported", effect)
+	}
+	if taint.Key == "" {
+		return taint, fmt.Errorf("node taint '%s' requires key", s)
+	}
+	return taint, nil
+}
+
+// Labels joined to kubelet node-labels argument
+func joinLabels(labels map[string]string) string {
+	pairs := []string{}
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
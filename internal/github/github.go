@@ -2,7 +2,6 @@ package github
 
 import (
 	"context"
-	"encoding/json"
 	"strings"
 
 	"github.com/kndpio/kndp/internal/registry"
@@ -29,9 +28,8 @@ func getAllPackages(ctx context.Context, client *github.Client, org string, opts
 
 // GetPackages list packages and their versions from Container Registry
 func GetPackages(ctx context.Context, query string, version bool, r *registry.Registry, registryUrl string, org string, logger *zap.SugaredLogger) (pterm.TableData, error) {
-	auth := registry.RegistryConfig{}
-	json.Unmarshal([]byte(r.Data[".dockerconfigjson"]), &auth)
-	clientgh := github.NewClient(nil).WithAuthToken(auth.Auths[registryUrl].Password)
+	auth, _ := r.Auth(registryUrl)
+	clientgh := github.NewClient(nil).WithAuthToken(auth.Password)
 	tableRegs := pterm.TableData{
 		{"URL", "VERSION"},
 	}
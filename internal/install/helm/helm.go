@@ -31,6 +31,7 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/client-go/rest"
 
@@ -93,10 +94,6 @@ type helmUpgrader interface {
 	action.Upgrade
 }
 
-type helmRollbacker interface {
-	Run(string) error
-}
-
 type helmUninstaller interface {
 	Run(name string) (*release.UninstallReleaseResponse, error)
 }
@@ -140,7 +137,8 @@ type Installer struct {
 	getClient       helmGetter
 	installClient   helmInstaller
 	upgradeClient   *action.Upgrade
-	rollbackClient  helmRollbacker
+	rollbackClient  *action.Rollback
+	historyClient   *action.History
 	uninstallClient helmUninstaller
 
 	// Loader
@@ -345,6 +343,9 @@ func NewManager(config *rest.Config, chartName string, repoURL *url.URL, release
 	rb.Timeout = waitTimeout
 	h.rollbackClient = rb
 
+	// History Client
+	h.historyClient = action.NewHistory(actionConfig)
+
 	return h, nil
 }
 
@@ -448,6 +449,7 @@ func (h *Installer) Upgrade(version string, parameters map[string]any, opts ...i
 	_, upErr := h.upgradeClient.Run(h.releaseName, helmChart, parameters)
 
 	if upErr != nil && h.rollbackOnError {
+		h.rollbackClient.Version = 0
 		if rErr := h.rollbackClient.Run(h.releaseName); rErr != nil {
 			return errors.Wrap(rErr, errFailedUpgradeFailedRollback)
 		}
@@ -456,9 +458,26 @@ func (h *Installer) Upgrade(version string, parameters map[string]any, opts ...i
 	return upErr
 }
 
+// Rollback rolls back release to revision, previous revision is used for 0.
+func (h *Installer) Rollback(revision int) error {
+	h.rollbackClient.Version = revision
+	return h.rollbackClient.Run(h.releaseName)
+}
+
+// History returns revisions of release, oldest first.
+func (h *Installer) History() ([]*release.Release, error) {
+	releases, err := h.historyClient.Run(h.releaseName)
+	if err != nil {
+		return nil, err
+	}
+	// Releases are listed in order of storage, not revisions
+	releaseutil.SortByRevision(releases)
+	return releases, nil
+}
+
 // Uninstall uninstalls an installation.
 func (h *Installer) Uninstall() error {
-	_, err := h.uninstallClient.Run(h.chartName)
+	_, err := h.uninstallClient.Run(h.releaseName)
 	return err
 }
 
@@ -32,6 +32,8 @@ type Manager interface {
 	GetRelease() (*release.Release, error)
 	Install(version string, parameters map[string]any, opts ...InstallOption) error
 	Upgrade(version string, parameters map[string]any, opts ...UpgradeOption) error
+	Rollback(revision int) error
+	History() ([]*release.Release, error)
 	Uninstall() error
 }
 
@@ -66,25 +66,33 @@ func NewImagePullApplicator(secret *SecretApplicator) *ImagePullApplicator {
 // Apply constructs an DockerConfig image pull Secret with the provided registry
 // and credentials.
 func (i *ImagePullApplicator) Apply(ctx context.Context, name, ns, user, pass, registry string) error {
-	regAuth := &create.DockerConfigJSON{
-		Auths: map[string]create.DockerConfigEntry{
-			registry: {
-				Username: user,
-				Password: pass,
-				Auth:     encodeDockerConfigFieldAuth(user, pass),
-			},
+	return i.ApplyConfig(ctx, metav1.ObjectMeta{Name: name}, ns, map[string]create.DockerConfigEntry{
+		registry: {
+			Username: user,
+			Password: pass,
+			Auth:     encodeDockerConfigFieldAuth(user, pass),
 		},
+	})
+}
+
+// ApplyConfig constructs a DockerConfig image pull Secret with credentials of
+// several registries, keyed by registry host. Labels and annotations of passed
+// metadata are kept on the Secret.
+func (i *ImagePullApplicator) ApplyConfig(ctx context.Context, meta metav1.ObjectMeta, ns string, auths map[string]create.DockerConfigEntry) error {
+	for registry, auth := range auths {
+		if auth.Auth == "" {
+			auth.Auth = encodeDockerConfigFieldAuth(auth.Username, auth.Password)
+			auths[registry] = auth
+		}
 	}
-	regAuthJSON, err := json.Marshal(regAuth)
+	regAuthJSON, err := json.Marshal(&create.DockerConfigJSON{Auths: auths})
 	if err != nil {
 		return err
 	}
 
 	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
-		},
-		Type: corev1.SecretTypeDockerConfigJson,
+		ObjectMeta: meta,
+		Type:       corev1.SecretTypeDockerConfigJson,
 		Data: map[string][]byte{
 			corev1.DockerConfigJsonKey: regAuthJSON,
 		},
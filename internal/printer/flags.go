@@ -0,0 +1,17 @@
+package printer
+
+import "github.com/alecthomas/kong"
+
+// Output flag of commands which print lists, embedded to command to bind its Printer
+type Output struct {
+	Output string `short:"o" help:"Output format of lists: table, wide, json, yaml, name, go-template=<template>, jsonpath=<path>" default:"table"`
+}
+
+func (o *Output) AfterApply(ctx *kong.Context) error {
+	p, err := New(o.Output)
+	if err != nil {
+		return err
+	}
+	ctx.Bind(p)
+	return nil
+}
@@ -0,0 +1,198 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/pterm/pterm"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	FormatTable = "table"
+	FormatWide  = "wide"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatName  = "name"
+
+	templatePrefix = "go-template="
+	jsonPathPrefix = "jsonpath="
+)
+
+// Column of table, wide columns are printed only in wide format
+type Column struct {
+	Header string
+	Wide   bool
+}
+
+// Row of table, object of row is printed in structured formats
+type Row struct {
+	Name   string
+	Cells  []string
+	Object any
+}
+
+// Table of listed entities
+type Table struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// Printer prints tables in requested output format
+type Printer struct {
+	format   string
+	template string
+	out      io.Writer
+}
+
+// New Printer for output format
+func New(output string) (*Printer, error) {
+	p := &Printer{
+		format: output,
+		out:    os.Stdout,
+	}
+	switch {
+	case output == "":
+		p.format = FormatTable
+	case output == FormatTable, output == FormatWide, output == FormatJSON, output == FormatYAML, output == FormatName:
+	case strings.HasPrefix(output, templatePrefix):
+		p.format = strings.TrimSuffix(templatePrefix, "=")
+		p.template = strings.TrimPrefix(output, templatePrefix)
+	case strings.HasPrefix(output, jsonPathPrefix):
+		p.format = strings.TrimSuffix(jsonPathPrefix, "=")
+		p.template = strings.TrimPrefix(output, jsonPathPrefix)
+	default:
+		return nil, fmt.Errorf("output format '%s' not supported, use one of: table, wide, json, yaml, name, %s..., %s...", output, templatePrefix, jsonPathPrefix)
+	}
+	return p, nil
+}
+
+// Writer where printer prints output
+func (p *Printer) WithWriter(out io.Writer) *Printer {
+	p.out = out
+	return p
+}
+
+// Check if output is intended for machines
+func (p *Printer) Structured() bool {
+	return p.format != FormatTable && p.format != FormatWide
+}
+
+// Print table in output format of printer
+func (p *Printer) Print(t Table) error {
+	switch p.format {
+	case FormatTable:
+		return p.printTable(t, false)
+	case FormatWide:
+		return p.printTable(t, true)
+	case FormatName:
+		for _, row := range t.Rows {
+			fmt.Fprintln(p.out, row.Name)
+		}
+		return nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(t.objects(), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out, string(data))
+		return err
+	case FormatYAML:
+		data, err := yaml.Marshal(t.objects())
+		if err != nil {
+			return err
+		}
+		_, err = p.out.Write(data)
+		return err
+	default:
+		return p.printTemplate(t)
+	}
+}
+
+func (p *Printer) printTable(t Table, wide bool) error {
+	header := []string{}
+	for _, column := range t.Columns {
+		if wide || !column.Wide {
+			header = append(header, column.Header)
+		}
+	}
+	data := pterm.TableData{header}
+	for _, row := range t.Rows {
+		cells := []string{}
+		for i, column := range t.Columns {
+			if wide || !column.Wide {
+				cell := ""
+				if i < len(row.Cells) {
+					cell = row.Cells[i]
+				}
+				cells = append(cells, cell)
+			}
+		}
+		data = append(data, cells)
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(p.out).Render()
+}
+
+// Apply Go template or JSONPath to list of objects
+func (p *Printer) printTemplate(t Table) error {
+	list := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      t.objects(),
+	}
+	// Round trip to generic values, so templates are able to use JSON names of fields
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	var data any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+
+	if p.format == strings.TrimSuffix(jsonPathPrefix, "=") {
+		jp := jsonpath.New("output").AllowMissingKeys(true)
+		if err := jp.Parse(p.template); err != nil {
+			return fmt.Errorf("error parsing jsonpath %s: %v", p.template, err)
+		}
+		if err := jp.Execute(p.out, data); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out)
+		return err
+	}
+
+	tmpl, err := template.New("output").Parse(p.template)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s: %v", p.template, err)
+	}
+	if err := tmpl.Execute(p.out, data); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.out)
+	return err
+}
+
+// Objects of rows, cells mapped by column headers are used for rows without objects
+func (t Table) objects() []any {
+	objects := []any{}
+	for _, row := range t.Rows {
+		if row.Object != nil {
+			objects = append(objects, row.Object)
+			continue
+		}
+		object := map[string]string{}
+		for i, column := range t.Columns {
+			if i < len(row.Cells) {
+				object[strings.ToLower(column.Header)] = row.Cells[i]
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
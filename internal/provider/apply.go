@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"time"
 
 	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
 	"go.uber.org/zap"
@@ -9,7 +10,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/kndpio/kndp/internal/engine"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -34,3 +37,34 @@ func (p *Provider) ApplyProvider(ctx context.Context, links []string, config *re
 	logger.Info("Provider(s) applied successfully.")
 	return nil
 }
+
+// Wait until providers of packages are healthy, or timeout is reached
+func HealthCheck(ctx context.Context, dc dynamic.Interface, links []string, timeoutChan <-chan time.Time, logger *zap.SugaredLogger) error {
+	linkSet := make(map[string]struct{})
+	for _, link := range links {
+		linkSet[link] = struct{}{}
+	}
+
+	for {
+		select {
+		case <-timeoutChan:
+			logger.Error("Timeout reached.")
+			return errors.New("timeout waiting for provider(s) to become healthy")
+		default:
+			allHealthy := true
+			for _, p := range ListProviders(ctx, dc, logger) {
+				if _, linkMatched := linkSet[p.Spec.Package]; linkMatched {
+					if p.GetCondition(crossv1.TypeHealthy).Status != corev1.ConditionTrue {
+						allHealthy = false
+						break
+					}
+				}
+			}
+			if allHealthy {
+				logger.Info("Provider(s) are healthy.")
+				return nil
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}
+}
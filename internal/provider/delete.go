@@ -24,6 +24,8 @@ func DeleteProvider(ctx context.Context, configClient *rest.Config, url string,
 	if err == nil {
 		params = release.Config
 	}
+	// Only providers are changed, so installed version is kept
+	version := engine.ReleaseVersion(release)
 
 	provider := params["provider"].(map[string]any)
 	packages, ok := provider["packages"].([]any)
@@ -42,7 +44,7 @@ func DeleteProvider(ctx context.Context, configClient *rest.Config, url string,
 	params["provider"] = provider
 
 	logger.Debug("Installing engine")
-	err = engine.InstallEngine(ctx, configClient, params, logger)
+	err = engine.InstallEngine(ctx, configClient, version, params, logger)
 	if err != nil {
 		return err
 	}
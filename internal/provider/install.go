@@ -35,7 +35,7 @@ func InstallProvider(provider string, config *rest.Config, logger *zap.SugaredLo
 		release.Config["provider"] = configs
 	}
 
-	err = installer.Upgrade(engine.Version, release.Config)
+	err = installer.Upgrade(engine.ReleaseVersion(release), release.Config)
 	if err != nil {
 		return err
 	}
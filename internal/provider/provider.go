@@ -9,8 +9,11 @@ import (
 	provider "github.com/crossplane/crossplane/apis/pkg/v1"
 	"github.com/kndpio/kndp/internal/kube"
 	"github.com/kndpio/kndp/internal/packages"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 )
 
@@ -65,3 +68,32 @@ func ListProviders(ctx context.Context, dynamicClient dynamic.Interface, logger
 	}
 	return providers
 }
+
+// Create providers in destination, existing ones are skipped
+func MoveProviders(ctx context.Context, logger *zap.SugaredLogger, destClientset dynamic.Interface, providers []unstructured.Unstructured) error {
+	if len(providers) == 0 {
+		logger.Warn("Provider resources not found")
+		return nil
+	}
+	for _, item := range providers {
+		item.SetResourceVersion("")
+		item.SetUID("")
+		_, err := destClientset.Resource(ResourceId()).Create(ctx, &item, metav1.CreateOptions{})
+		if kerrors.IsAlreadyExists(err) {
+			logger.Warnf("Provider %s already exists, skipping.", item.GetName())
+		} else if err != nil {
+			return err
+		} else {
+			logger.Infof("Provider created successfully %s", item.GetName())
+		}
+	}
+	return nil
+}
+
+func ResourceId() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "pkg.crossplane.io",
+		Version:  "v1",
+		Resource: "providers",
+	}
+}
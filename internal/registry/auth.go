@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Username stored with token, registries authenticating by token ignore it
+const TokenUsername = "kndp"
+
+// Credentials of registry server, in format of docker config entry
+type RegistryAuth struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Email    string `json:"email,omitempty" validate:"omitempty,email"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// Docker config of registry secret, auths are keyed by registry hostname
+type RegistryConfig struct {
+	Auths map[string]RegistryAuth `json:"auths"`
+}
+
+// Credentials of username and password
+func NewAuth(username string, password string, email string) RegistryAuth {
+	return RegistryAuth{
+		Username: username,
+		Password: password,
+		Email:    email,
+		Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+	}
+}
+
+// Credentials of access token, username is optional for token
+func TokenAuth(username string, token string) RegistryAuth {
+	if username == "" {
+		username = TokenUsername
+	}
+	return NewAuth(username, token, "")
+}
+
+// Credentials of server from docker config and credential helpers
+func DockerConfigAuth(server string) (RegistryAuth, error) {
+	registry, err := name.NewRegistry(ServerHost(server))
+	if err != nil {
+		return RegistryAuth{}, err
+	}
+	authenticator, err := authn.DefaultKeychain.Resolve(registry)
+	if err != nil {
+		return RegistryAuth{}, err
+	}
+	if authenticator == authn.Anonymous {
+		return RegistryAuth{}, fmt.Errorf("credentials of %s not found in docker config", server)
+	}
+	config, err := authenticator.Authorization()
+	if err != nil {
+		return RegistryAuth{}, err
+	}
+	if config.IdentityToken != "" || config.RegistryToken != "" {
+		return RegistryAuth{}, fmt.Errorf("credentials of %s in docker config are identity token, which is not supported by image pull secrets", server)
+	}
+	if config.Username == "" && config.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(config.Auth)
+		if err != nil {
+			return RegistryAuth{}, fmt.Errorf("failed to decode credentials of %s from docker config: %v", server, err)
+		}
+		config.Username, config.Password, _ = strings.Cut(string(decoded), ":")
+	}
+	return NewAuth(config.Username, config.Password, ""), nil
+}
+
+// Host of server, which is provided with or without scheme and path
+func ServerHost(server string) string {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return strings.SplitN(strings.TrimPrefix(server, "https://"), "/", 2)[0]
+	}
+	return u.Host
+}
+
+// Hostname used by clients for server, such as index.docker.io for docker.io
+func registryHost(server string) (string, error) {
+	registry, err := name.NewRegistry(ServerHost(server))
+	if err != nil {
+		return "", fmt.Errorf("invalid registry server %s: %v", server, err)
+	}
+	return registry.RegistryStr(), nil
+}
+
+// Name of secret for registry host, port separator isn't allowed in names
+func secretName(host string) string {
+	return strings.ReplaceAll(strings.ToLower(host), ":", "-")
+}
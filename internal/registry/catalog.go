@@ -0,0 +1,401 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/kndpio/kndp/internal/kube"
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// Image tagged in local registry
+type LocalImage struct {
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	Digest     string    `json:"digest"`
+	Size       int64     `json:"size"`
+	Created    time.Time `json:"created,omitempty"`
+	InUse      bool      `json:"inUse"`
+}
+
+// Reference of image in local registry
+func (i LocalImage) Reference() string {
+	return i.Repository + ":" + i.Tag
+}
+
+// List images of local registry repository, all repositories are listed when it isn't provided.
+// Images referenced by installed packages and their revisions are marked in use.
+func ListLocalImages(ctx context.Context, config *rest.Config, repository string, logger *zap.SugaredLogger) ([]LocalImage, error) {
+	used, err := usedImages(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	images := []LocalImage{}
+	err = withLocalRegistry(ctx, config, logger, func(host string, options []remote.Option) error {
+		var err error
+		images, err = localImages(ctx, host, repository, options)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		images[i].InUse = used[images[i].Reference()] || used[images[i].Digest]
+	}
+	return images, nil
+}
+
+// Delete image of local registry by reference, manifest is deleted with all its tags.
+// Image referenced by installed packages is deleted only when forced.
+func DeleteLocalImage(ctx context.Context, config *rest.Config, reference string, force bool, logger *zap.SugaredLogger) ([]LocalImage, error) {
+	repository, identifier := splitReference(reference)
+	images, err := ListLocalImages(ctx, config, repository, logger)
+	if err != nil {
+		return nil, err
+	}
+	digest := ""
+	for _, image := range images {
+		if image.Tag == identifier || image.Digest == identifier {
+			digest = image.Digest
+		}
+	}
+	if digest == "" {
+		return nil, fmt.Errorf("image %s not found in local registry", reference)
+	}
+	deleted := []LocalImage{}
+	for _, image := range images {
+		if image.Digest != digest {
+			continue
+		}
+		if image.InUse && !force {
+			return nil, fmt.Errorf("image %s is used by installed packages", image.Reference())
+		}
+		deleted = append(deleted, image)
+	}
+	return deleted, deleteLocalImages(ctx, config, deleted, logger)
+}
+
+// Find images of local registry repositories, which are older than keep newest tags and not used by installed packages.
+// Tags are ordered by semantic version, tags which aren't versions by creation time.
+func LocalGarbage(ctx context.Context, config *rest.Config, repository string, keep int, logger *zap.SugaredLogger) ([]LocalImage, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("number of kept images can't be negative")
+	}
+	images, err := ListLocalImages(ctx, config, repository, logger)
+	if err != nil {
+		return nil, err
+	}
+	repositories := map[string][]LocalImage{}
+	for _, image := range images {
+		repositories[image.Repository] = append(repositories[image.Repository], image)
+	}
+
+	garbage := []LocalImage{}
+	for _, tagged := range repositories {
+		sort.SliceStable(tagged, func(i, j int) bool {
+			return newerImage(tagged[i], tagged[j])
+		})
+		// Manifest is deleted with all its tags, so manifests of kept tags are kept
+		kept := map[string]bool{}
+		for i, image := range tagged {
+			if i < keep || image.InUse {
+				kept[image.Digest] = true
+			}
+		}
+		for _, image := range tagged {
+			if !kept[image.Digest] {
+				garbage = append(garbage, image)
+			}
+		}
+	}
+	sort.Slice(garbage, func(i, j int) bool {
+		return garbage[i].Reference() < garbage[j].Reference()
+	})
+	return garbage, nil
+}
+
+// Delete garbage images of local registry, confirmation is prompted unless forced
+func CollectLocalGarbage(ctx context.Context, config *rest.Config, garbage []LocalImage, f bool, logger *zap.SugaredLogger) error {
+	if len(garbage) == 0 {
+		return nil
+	}
+	if !f && !confirmationPrompt(fmt.Sprintf("Do you really want to remove %d images from local registry ?", len(garbage)), logger) {
+		return nil
+	}
+	return deleteLocalImages(ctx, config, garbage, logger)
+}
+
+// Delete manifests of images and remove blobs, which aren't referenced anymore, from storage of local registry
+func deleteLocalImages(ctx context.Context, config *rest.Config, images []LocalImage, logger *zap.SugaredLogger) error {
+	if len(images) == 0 {
+		return nil
+	}
+	err := withLocalRegistry(ctx, config, logger, func(host string, options []remote.Option) error {
+		deleted := map[string]bool{}
+		for _, image := range images {
+			if deleted[image.Repository+"@"+image.Digest] {
+				continue
+			}
+			ref, err := name.NewDigest(host + "/" + image.Repository + "@" + image.Digest)
+			if err != nil {
+				return err
+			}
+			logger.Infof("Deleting image %s", image.Reference())
+			err = remote.Delete(ref, options...)
+			if isUnsupported(err) {
+				return fmt.Errorf("deletion isn't enabled in local registry, create local registry again to enable it: %v", err)
+			}
+			if err != nil {
+				return err
+			}
+			deleted[image.Repository+"@"+image.Digest] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return collectLocalBlobs(ctx, config, logger)
+}
+
+// Run garbage collection of local registry, deleted manifests keep their blobs in storage until it runs
+func collectLocalBlobs(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
+	}
+	pod, err := WaitLocalRegistry(ctx, client, LocalReadyTimeout)
+	if err != nil {
+		return err
+	}
+	logger.Info("Removing unreferenced blobs from storage of local registry")
+	out, err := execLocalRegistry(ctx, pod, config, "registry", "garbage-collect", localConfigPath)
+	if len(out) != 0 {
+		logger.Debug(out)
+	}
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	logger.Infof("Storage of local registry collected: %s", lines[len(lines)-1])
+	return nil
+}
+
+// confirmationPrompt prompts the user with a yes/no choice.
+func confirmationPrompt(s string, logger *zap.SugaredLogger) bool {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("%s [y/n]: ", s)
+		r, err := reader.ReadString('\n')
+		if err != nil {
+			logger.Error(err)
+		}
+		r = strings.ToLower(strings.TrimSpace(r))
+		switch r {
+		case "y", "yes":
+			return true
+		case "n", "no", "":
+			logger.Info("Aborting...")
+			return false
+		}
+	}
+}
+
+// Tagged images of repositories in registry available on host
+func localImages(ctx context.Context, host string, repository string, options []remote.Option) ([]LocalImage, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return nil, err
+	}
+	repositories := []string{repository}
+	if repository == "" {
+		repositories, err = remote.Catalog(ctx, registry, options...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	images := []LocalImage{}
+	for _, repositoryName := range repositories {
+		repo, err := name.NewRepository(host + "/" + repositoryName)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := remote.List(repo, options...)
+		if isNotFound(err) && repository == "" {
+			// Repository of catalog without tags
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			image, err := remote.Image(repo.Tag(tag), options...)
+			if err != nil {
+				return nil, err
+			}
+			digest, err := image.Digest()
+			if err != nil {
+				return nil, err
+			}
+			manifest, err := image.Manifest()
+			if err != nil {
+				return nil, err
+			}
+			size := manifest.Config.Size
+			for _, layer := range manifest.Layers {
+				size += layer.Size
+			}
+			localImage := LocalImage{
+				Repository: repositoryName,
+				Tag:        tag,
+				Digest:     digest.String(),
+				Size:       size,
+			}
+			if configFile, err := image.ConfigFile(); err == nil {
+				localImage.Created = configFile.Created.Time
+			}
+			images = append(images, localImage)
+		}
+	}
+	return images, nil
+}
+
+// Images referenced by installed packages and their revisions, by tagged reference and digest.
+// References of local registry are also added without its domain, as they are pushed so.
+func usedImages(ctx context.Context, config *rest.Config) (map[string]bool, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	used := map[string]bool{}
+	add := func(image string) {
+		if image == "" {
+			return
+		}
+		used[image] = true
+		ref, err := name.ParseReference(image, name.WithDefaultRegistry(DefaultRemoteDomain))
+		if err != nil {
+			return
+		}
+		if strings.HasPrefix(ref.Identifier(), "sha256:") {
+			used[ref.Identifier()] = true
+		}
+		if ref.Context().RegistryStr() == DefaultLocalDomain {
+			used[ref.Context().RepositoryStr()+":"+ref.Identifier()] = true
+		}
+	}
+
+	list := func(gvr schema.GroupVersionResource, field ...string) error {
+		items, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if kerrors.IsNotFound(err) {
+			// Engine isn't installed
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, item := range items.Items {
+			image, _, _ := unstructured.NestedString(item.Object, field...)
+			add(image)
+		}
+		return nil
+	}
+	for _, gvr := range packageResources {
+		if err := list(gvr, "spec", "package"); err != nil {
+			return nil, err
+		}
+	}
+	for _, gvr := range revisionResources {
+		if err := list(gvr, "spec", "image"); err != nil {
+			return nil, err
+		}
+	}
+	return used, nil
+}
+
+// Run function with port forward host and remote options of ready local registry
+func withLocalRegistry(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger, f func(host string, options []remote.Option) error) error {
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
+	}
+	pod, err := WaitLocalRegistry(ctx, client, LocalReadyTimeout)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Found local registry with name: %s", pod.GetName())
+	options, err := localRemoteOptions(ctx, client)
+	if err != nil {
+		return err
+	}
+	return forwardLocalRegistry(ctx, pod, config, logger, func(host string) error {
+		return f(host, options)
+	})
+}
+
+// Newer image by semantic version of tags, or by creation time when tags aren't versions
+func newerImage(a LocalImage, b LocalImage) bool {
+	av, aerr := semver.NewVersion(a.Tag)
+	bv, berr := semver.NewVersion(b.Tag)
+	switch {
+	case aerr == nil && berr == nil && !av.Equal(bv):
+		return av.GreaterThan(bv)
+	case aerr == nil && berr != nil:
+		return true
+	case aerr != nil && berr == nil:
+		return false
+	case !a.Created.Equal(b.Created):
+		return a.Created.After(b.Created)
+	}
+	return a.Tag > b.Tag
+}
+
+// Repository and tag or digest of reference, tag is latest by default
+func splitReference(reference string) (string, string) {
+	if repository, digest, ok := strings.Cut(reference, "@"); ok {
+		return repository, digest
+	}
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i], reference[i+1:]
+	}
+	return reference, name.DefaultTag
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
+
+func isUnsupported(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	if terr.StatusCode == http.StatusMethodNotAllowed {
+		return true
+	}
+	for _, diagnostic := range terr.Errors {
+		if diagnostic.Code == transport.UnsupportedErrorCode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	CheckOK     = "OK"
+	CheckFailed = "Failed"
+)
+
+// Result of registry server check
+type CheckResult struct {
+	Registry string `json:"registry"`
+	Server   string `json:"server"`
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+}
+
+// Check credentials of registry servers by token handshake and listing of repository tags or catalog.
+// Catalog is listed when repository isn't provided, token registries without catalog are checked by handshake only.
+func (r *Registry) Check(ctx context.Context, repository string) []CheckResult {
+	hosts := []string{}
+	for host := range r.Config.Auths {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	results := []CheckResult{}
+	for _, host := range hosts {
+		result := CheckResult{Registry: r.Name, Server: host, Status: CheckOK}
+		message, err := checkServer(ctx, host, r.Config.Auths[host], repository)
+		if err != nil {
+			result.Status = CheckFailed
+			result.Message = err.Error()
+		} else {
+			result.Message = message
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Verify that credentials of all registry servers work
+func (r *Registry) Verify(ctx context.Context) error {
+	for _, result := range r.Check(ctx, "") {
+		if result.Status != CheckOK {
+			return fmt.Errorf("registry %s check failed: %s", result.Server, result.Message)
+		}
+	}
+	return nil
+}
+
+func checkServer(ctx context.Context, host string, auth RegistryAuth, repository string) (string, error) {
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return "", err
+	}
+	authenticator := authn.FromConfig(authn.AuthConfig{
+		Username: auth.Username,
+		Password: auth.Password,
+	})
+
+	if repository != "" {
+		repo, err := name.NewRepository(host + "/" + repository)
+		if err != nil {
+			return "", err
+		}
+		rt, err := transport.NewWithContext(ctx, registry, authenticator, http.DefaultTransport, []string{repo.Scope(transport.PullScope)})
+		if err != nil {
+			return "", authError(err)
+		}
+		tags, err := remote.List(repo, remote.WithContext(ctx), remote.WithTransport(rt))
+		if err != nil {
+			return "", authError(err)
+		}
+		if len(tags) == 0 {
+			return fmt.Sprintf("repository %s has no tags", repository), nil
+		}
+		return fmt.Sprintf("listed tag %s of repository %s", tags[0], repository), nil
+	}
+
+	// Handshake without scope checks credentials only, scope of catalog is requested by registry challenge
+	rt, err := transport.NewWithContext(ctx, registry, authenticator, http.DefaultTransport, nil)
+	if err != nil {
+		return "", authError(err)
+	}
+	repos, err := remote.CatalogPage(registry, "", 1, remote.WithContext(ctx), remote.WithTransport(rt))
+	if err != nil {
+		// Token exchange already checked credentials, registries like Docker Hub deny catalog to any user,
+		// credentials of other registries aren't checked until catalog is listed.
+		if (isAuthError(err) || isNotFound(err) || isUnsupported(err)) && tokenAuth(ctx, registry) {
+			if isAuthError(err) {
+				return "authenticated, catalog is not accessible", nil
+			}
+			return "authenticated, catalog is not supported by registry", nil
+		}
+		return "", authError(err)
+	}
+	if len(repos) == 0 {
+		return "authenticated, catalog is empty", nil
+	}
+	return fmt.Sprintf("listed repository %s", repos[0]), nil
+}
+
+// Check if registry authenticates by token, credentials are checked by token exchange then
+func tokenAuth(ctx context.Context, registry name.Registry) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registry.Scheme()+"://"+registry.RegistryStr()+"/v2/", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return strings.HasPrefix(strings.ToLower(resp.Header.Get("WWW-Authenticate")), "bearer")
+}
+
+func isAuthError(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+	if terr.StatusCode == http.StatusUnauthorized || terr.StatusCode == http.StatusForbidden {
+		return true
+	}
+	for _, diagnostic := range terr.Errors {
+		if diagnostic.Code == transport.UnauthorizedErrorCode || diagnostic.Code == transport.DeniedErrorCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Error of registry, auth errors are marked to tell them from connectivity errors
+func authError(err error) error {
+	if isAuthError(err) {
+		return fmt.Errorf("authentication failed: %v", err)
+	}
+	return err
+}
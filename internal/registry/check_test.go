@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Registry server with catalog responding by status, token is issued for user:password when token auth is set
+func testRegistry(t *testing.T, token bool, catalogStatus int) string {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		authorized := ok && user == "user" && password == "password"
+		switch {
+		case r.URL.Path == "/token":
+			if !authorized {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token":"token"}`)
+		case token && r.Header.Get("Authorization") != "Bearer token":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case !token && !authorized:
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v2/_catalog" && catalogStatus == http.StatusOK:
+			fmt.Fprint(w, `{"repositories":["app"]}`)
+		default:
+			w.WriteHeader(catalogStatus)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestCheckServer(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         bool
+		catalogStatus int
+		password      string
+		wantMessage   string
+		wantErr       bool
+	}{
+		{name: "catalog", token: true, catalogStatus: http.StatusOK, password: "password", wantMessage: "listed repository app"},
+		{name: "token without catalog", token: true, catalogStatus: http.StatusNotFound, password: "password", wantMessage: "authenticated, catalog is not supported by registry"},
+		{name: "token with catalog denied", token: true, catalogStatus: http.StatusForbidden, password: "password", wantMessage: "authenticated, catalog is not accessible"},
+		{name: "token with server error", token: true, catalogStatus: http.StatusInternalServerError, password: "password", wantErr: true},
+		{name: "token with wrong password", token: true, catalogStatus: http.StatusOK, password: "wrong", wantErr: true},
+		{name: "basic catalog", token: false, catalogStatus: http.StatusOK, password: "password", wantMessage: "listed repository app"},
+		{name: "basic without catalog", token: false, catalogStatus: http.StatusNotFound, password: "password", wantErr: true},
+		{name: "basic with wrong password", token: false, catalogStatus: http.StatusOK, password: "wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := testRegistry(t, tt.token, tt.catalogStatus)
+			message, err := checkServer(context.Background(), host, RegistryAuth{Username: "user", Password: tt.password}, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("checkServer() = %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+}
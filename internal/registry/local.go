@@ -3,25 +3,41 @@ package registry
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/kndpio/kndp/internal/kube"
+	"github.com/kndpio/kndp/internal/engine"
 	"github.com/kndpio/kndp/internal/namespace"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/client-go/transport/spdy"
 )
 
@@ -30,7 +46,29 @@ const (
 	svcName    = "registry"
 	deployPort = 5000
 	svcPort    = 80
+	svcTLSPort = 443
 	nodePort   = 30100
+
+	LocalStorageEmptyDir = "emptydir"
+	LocalStoragePVC      = "pvc"
+	LocalStorageHost     = "host"
+
+	// Default size of persistent volume claim of local registry
+	DefaultLocalStorageSize = "5Gi"
+	// Time to wait local registry is ready before push
+	LocalReadyTimeout = 2 * time.Minute
+
+	localDataPath    = "/var/lib/registry"
+	localConfigPath  = "/etc/docker/registry/config.yml"
+	localHostPath    = "/storage/registry"
+	localAuthPath    = "/auth"
+	localCertsPath   = "/certs"
+	localHtpasswdKey = "htpasswd"
+	localTLSName     = deployName + "-tls"
+	localAuthName    = deployName + "-htpasswd"
+	localCAName      = deployName + "-ca"
+	localCAKey       = "ca.crt"
+	localCertTTL     = 10 * 365 * 24 * time.Hour
 )
 
 var (
@@ -39,35 +77,158 @@ var (
 	}
 )
 
+// Options of in cluster registry. Storage is emptydir, when it isn't set, pvc or
+// host, which is /storage mount of environment. Auth is enabled by username and password.
+type LocalOptions struct {
+	Storage     string
+	StorageSize string
+	TLS         bool
+	Username    string
+	Password    string
+}
+
+// Validate options of local registry
+func (o LocalOptions) Validate() error {
+	switch o.Storage {
+	case "", LocalStorageEmptyDir, LocalStoragePVC, LocalStorageHost:
+	default:
+		return fmt.Errorf("local registry storage '%s' not supported, supported are %s, %s and %s", o.Storage, LocalStorageEmptyDir, LocalStoragePVC, LocalStorageHost)
+	}
+	if o.StorageSize != "" {
+		if o.Storage != LocalStoragePVC {
+			return fmt.Errorf("local registry storage size requires %s storage", LocalStoragePVC)
+		}
+		if _, err := resource.ParseQuantity(o.StorageSize); err != nil {
+			return fmt.Errorf("invalid local registry storage size %s: %v", o.StorageSize, err)
+		}
+	}
+	if (o.Username == "") != (o.Password == "") {
+		return fmt.Errorf("local registry auth requires both username and password")
+	}
+	return nil
+}
+
 // Create in cluster registry
-func (r *Registry) CreateLocal(ctx context.Context, client *kubernetes.Clientset) error {
+func (r *Registry) CreateLocal(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	o := r.LocalOptions
+	container := corev1.Container{
+		Name:  "registry",
+		Image: "registry:2",
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "oci",
+				Protocol:      corev1.ProtocolTCP,
+				ContainerPort: deployPort,
+			},
+		},
+		Env: []corev1.EnvVar{
+			// Images are deleted by garbage collection of local registry
+			{Name: "REGISTRY_STORAGE_DELETE_ENABLED", Value: "true"},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(deployPort),
+				},
+			},
+			PeriodSeconds: 2,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "data",
+				MountPath: localDataPath,
+			},
+		},
+	}
+	podSpec := corev1.PodSpec{}
+	strategy := appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+
+	switch o.Storage {
+	case LocalStoragePVC:
+		logger.Debug("Use persistent volume claim as local registry storage")
+		err := applyLocalPVC(ctx, client, valueOrDefault(o.StorageSize, DefaultLocalStorageSize))
+		if err != nil {
+			return err
+		}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: deployName},
+			},
+		})
+		// Volume can't be attached to pods of both replica sets
+		strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	case LocalStorageHost:
+		logger.Debugf("Use %s of environment as local registry storage", localHostPath)
+		hostPathType := corev1.HostPathDirectoryOrCreate
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: localHostPath, Type: &hostPathType},
+			},
+		})
+		strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	default:
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         "data",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+	}
+
+	if o.Username != "" {
+		logger.Debug("Enable htpasswd auth of local registry")
+		err := applyLocalHtpasswd(ctx, client, o.Username, o.Password)
+		if err != nil {
+			return err
+		}
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "REGISTRY_AUTH", Value: "htpasswd"},
+			corev1.EnvVar{Name: "REGISTRY_AUTH_HTPASSWD_REALM", Value: deployName},
+			corev1.EnvVar{Name: "REGISTRY_AUTH_HTPASSWD_PATH", Value: localAuthPath + "/" + localHtpasswdKey},
+		)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "auth", MountPath: localAuthPath, ReadOnly: true})
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         "auth",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: localAuthName}},
+		})
+	}
+
+	port := svcPort
+	if o.TLS {
+		logger.Debug("Enable TLS of local registry")
+		err := applyLocalCertificate(ctx, client)
+		if err != nil {
+			return err
+		}
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "REGISTRY_HTTP_TLS_CERTIFICATE", Value: localCertsPath + "/" + corev1.TLSCertKey},
+			corev1.EnvVar{Name: "REGISTRY_HTTP_TLS_KEY", Value: localCertsPath + "/" + corev1.TLSPrivateKeyKey},
+		)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "certs", MountPath: localCertsPath, ReadOnly: true})
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         "certs",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: localTLSName}},
+		})
+		// Clients connect to https registry without port on default https port
+		port = svcTLSPort
+	}
+	podSpec.Containers = []corev1.Container{container}
+
 	deploy := &appsv1.Deployment{
 		ObjectMeta: v1.ObjectMeta{
-			Name: deployName,
+			Name:   deployName,
+			Labels: engine.ManagedLabels(nil),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &v1.LabelSelector{
 				MatchLabels: matchLabels,
 			},
+			Strategy: strategy,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: v1.ObjectMeta{
 					Labels: matchLabels,
 				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  "registry",
-							Image: "registry:2",
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "oci",
-									Protocol:      corev1.ProtocolTCP,
-									ContainerPort: deployPort,
-								},
-							},
-						},
-					},
-				},
+				Spec: podSpec,
 			},
 		},
 	}
@@ -89,7 +250,8 @@ func (r *Registry) CreateLocal(ctx context.Context, client *kubernetes.Clientset
 
 	svc := &corev1.Service{
 		ObjectMeta: v1.ObjectMeta{
-			Name: svcName,
+			Name:   svcName,
+			Labels: engine.ManagedLabels(nil),
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     "NodePort",
@@ -99,7 +261,7 @@ func (r *Registry) CreateLocal(ctx context.Context, client *kubernetes.Clientset
 					Name:       "oci",
 					Protocol:   corev1.ProtocolTCP,
 					NodePort:   nodePort,
-					Port:       svcPort,
+					Port:       int32(port),
 					TargetPort: intstr.FromInt(deployPort),
 				},
 			},
@@ -123,31 +285,191 @@ func (r *Registry) CreateLocal(ctx context.Context, client *kubernetes.Clientset
 	return nil
 }
 
-// Delete in cluster registry
-func (r *Registry) DeleteLocal(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
-	svcs := client.CoreV1().Services(namespace.Namespace)
-	eSvc, _ := svcs.Get(ctx, svcName, v1.GetOptions{})
-	if eSvc != nil {
-		err := svcs.Delete(ctx, svcName, v1.DeleteOptions{})
+// Create persistent volume claim of local registry, existing claim is kept, as its size can't be decreased
+func applyLocalPVC(ctx context.Context, client *kubernetes.Clientset, size string) error {
+	claims := client.CoreV1().PersistentVolumeClaims(namespace.Namespace)
+	_, err := claims.Get(ctx, deployName, v1.GetOptions{})
+	if err == nil || !kerrors.IsNotFound(err) {
+		return err
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return err
+	}
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   deployName,
+			Labels: engine.ManagedLabels(nil),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: quantity},
+			},
+		},
+	}
+	_, err = claims.Create(ctx, claim, v1.CreateOptions{})
+	return err
+}
+
+// Create or update secret with htpasswd file of local registry user
+func applyLocalHtpasswd(ctx context.Context, client *kubernetes.Clientset, username string, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   localAuthName,
+			Labels: engine.ManagedLabels(nil),
+		},
+		Data: map[string][]byte{
+			localHtpasswdKey: []byte(username + ":" + string(hash) + "\n"),
+		},
+	}
+	return applyLocalSecret(ctx, client, secret)
+}
+
+// Create self-signed certificate of local registry and config map with it for engine,
+// certificate is created once, so clients trusting it keep working after update.
+func applyLocalCertificate(ctx context.Context, client *kubernetes.Clientset) error {
+	secrets := client.CoreV1().Secrets(namespace.Namespace)
+	secret, err := secrets.Get(ctx, localTLSName, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		cert, key, err := localCertificate()
 		if err != nil {
 			return err
 		}
-	} else {
-		logger.Warnf("Service %s not found", svcName)
-	}
-	deployments := client.AppsV1().Deployments(namespace.Namespace)
-	eDeploy, _ := deployments.Get(ctx, deployName, v1.GetOptions{})
-	if eDeploy != nil {
-		err := deployments.Delete(ctx, deployName, v1.DeleteOptions{})
+		secret = &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   localTLSName,
+				Labels: engine.ManagedLabels(nil),
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       cert,
+				corev1.TLSPrivateKeyKey: key,
+			},
+		}
+		secret, err = secrets.Create(ctx, secret, v1.CreateOptions{})
 		if err != nil {
 			return err
 		}
-	} else {
+	} else if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   localCAName,
+			Labels: engine.ManagedLabels(nil),
+		},
+		Data: map[string]string{
+			localCAKey: string(secret.Data[corev1.TLSCertKey]),
+		},
+	}
+	configMaps := client.CoreV1().ConfigMaps(namespace.Namespace)
+	_, err = configMaps.Get(ctx, localCAName, v1.GetOptions{})
+	if err == nil {
+		_, err = configMaps.Update(ctx, configMap, v1.UpdateOptions{})
+	} else if kerrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, configMap, v1.CreateOptions{})
+	}
+	return err
+}
+
+// Self-signed certificate of local registry service, also valid for port forward to localhost
+func localCertificate() ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: DefaultLocalDomain},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(localCertTTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+		DNSNames: []string{
+			svcName,
+			svcName + "." + namespace.Namespace,
+			svcName + "." + namespace.Namespace + ".svc",
+			DefaultLocalDomain,
+			"localhost",
+		},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), nil
+}
+
+func applyLocalSecret(ctx context.Context, client *kubernetes.Clientset, secret *corev1.Secret) error {
+	secrets := client.CoreV1().Secrets(namespace.Namespace)
+	_, err := secrets.Get(ctx, secret.Name, v1.GetOptions{})
+	if err == nil {
+		_, err = secrets.Update(ctx, secret, v1.UpdateOptions{})
+	} else if kerrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, secret, v1.CreateOptions{})
+	}
+	return err
+}
+
+// Delete in cluster registry with its storage, certificate and credentials
+func (r *Registry) DeleteLocal(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	err := client.CoreV1().Services(namespace.Namespace).Delete(ctx, svcName, v1.DeleteOptions{})
+	if kerrors.IsNotFound(err) {
+		logger.Warnf("Service %s not found", svcName)
+	} else if err != nil {
+		return err
+	}
+	err = client.AppsV1().Deployments(namespace.Namespace).Delete(ctx, deployName, v1.DeleteOptions{})
+	if kerrors.IsNotFound(err) {
 		logger.Warnf("Deployment %s not found", deployName)
+	} else if err != nil {
+		return err
+	}
+	for _, name := range []string{localTLSName, localAuthName, secretName(DefaultLocalDomain)} {
+		err = client.CoreV1().Secrets(namespace.Namespace).Delete(ctx, name, v1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+	}
+	err = client.CoreV1().ConfigMaps(namespace.Namespace).Delete(ctx, localCAName, v1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	err = client.CoreV1().PersistentVolumeClaims(namespace.Namespace).Delete(ctx, deployName, v1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
 	}
 	return nil
 }
 
+// Check if local registry is installed, by its deployment
+func LocalRegistryExists(ctx context.Context, client *kubernetes.Clientset) (bool, error) {
+	_, err := client.AppsV1().Deployments(namespace.Namespace).Get(ctx, deployName, v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func IsLocalRegistry(ctx context.Context, client *kubernetes.Clientset) (bool, error) {
 
 	pods := client.CoreV1().Pods(namespace.Namespace)
@@ -161,19 +483,58 @@ func IsLocalRegistry(ctx context.Context, client *kubernetes.Clientset) (bool, e
 	return true, nil
 }
 
-func PushLocalRegistry(ctx context.Context, imageName string, image regv1.Image, config *rest.Config, logger *zap.SugaredLogger) error {
-
-	client, err := kube.Client(config)
+// Wait for ready pod of local registry
+func WaitLocalRegistry(ctx context.Context, client *kubernetes.Clientset, timeout time.Duration) (*corev1.Pod, error) {
+	var ready *corev1.Pod
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		pods, err := client.CoreV1().Pods(namespace.Namespace).List(ctx, v1.ListOptions{LabelSelector: "app=" + deployName})
+		if err != nil {
+			return false, err
+		}
+		for i, pod := range pods.Items {
+			if pod.DeletionTimestamp == nil && podReady(pod) {
+				ready = &pods.Items[i]
+				return true, nil
+			}
+		}
+		return false, nil
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("local registry is not ready: %v", err)
 	}
+	return ready, nil
+}
 
-	pods := client.CoreV1().Pods(namespace.Namespace)
-	regs, err := pods.List(ctx, v1.ListOptions{Limit: 1, LabelSelector: "app=" + deployName})
-	if err != nil {
-		return err
+func podReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
 	}
+	return false
+}
+
+// Push image to local registry through port forward, credentials and certificate of local registry are used when enabled
+func PushLocalRegistry(ctx context.Context, imageName string, image regv1.Image, config *rest.Config, logger *zap.SugaredLogger) error {
 
+	return withLocalRegistry(ctx, config, logger, func(host string, options []remote.Option) error {
+		refName := host + "/" + imageName
+		logger.Debugf("Try to push to reference: %s", refName)
+		ref, err := name.ParseReference(refName)
+		if err != nil {
+			return err
+		}
+		err = remote.Write(ref, image, options...)
+		if err != nil {
+			return err
+		}
+		logger.Debug("Pushed to remote registry.")
+		return nil
+	})
+}
+
+// Run function with host of port forward to local registry pod, port forward is stopped when it returns
+func forwardLocalRegistry(ctx context.Context, pod *corev1.Pod, config *rest.Config, logger *zap.SugaredLogger, f func(host string) error) error {
 	roundTripper, upgrader, err := spdy.RoundTripperFor(config)
 	if err != nil {
 		return err
@@ -184,9 +545,7 @@ func PushLocalRegistry(ctx context.Context, imageName string, image regv1.Image,
 		return err
 	}
 
-	logger.Debugf("Found local registry with name: %s", regs.Items[0].GetName())
-
-	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace.Namespace, regs.Items[0].GetName())
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace.Namespace, pod.GetName())
 	hostIP := strings.TrimLeft(config.Host, "htps:/")
 	serverURL := url.URL{Scheme: "https", Path: path, Host: hostIP}
 
@@ -200,32 +559,85 @@ func PushLocalRegistry(ctx context.Context, imageName string, image regv1.Image,
 		return err
 	}
 
+	forwardErr := make(chan error, 1)
 	go func() {
-		for range readyChan {
-		}
-		if len(errOut.String()) != 0 {
-			close(stopChan)
-		} else if len(out.String()) != 0 {
-			logger.Debug(out.String())
-		}
-		refName := "localhost:" + fmt.Sprint(lPort) + "/" + imageName
-		logger.Debugf("Try to push to reference: %s", refName)
-		ref, err := name.ParseReference(refName)
-		if err != nil {
-			close(stopChan)
-		}
-		err = remote.Write(ref, image)
-		if err != nil {
-			close(stopChan)
-		}
-		logger.Debug("Pushed to remote registry.")
-		close(stopChan)
+		forwardErr <- forwarder.ForwardPorts()
 	}()
+	defer close(stopChan)
 
-	if err = forwarder.ForwardPorts(); err != nil {
-		return err
+	select {
+	case <-readyChan:
+	case err := <-forwardErr:
+		return fmt.Errorf("port forward to local registry failed: %v %s", err, errOut.String())
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return nil
+	if len(out.String()) != 0 {
+		logger.Debug(out.String())
+	}
+	return f("localhost:" + fmt.Sprint(lPort))
+}
+
+// Run command in container of local registry pod, its output is returned
+func execLocalRegistry(ctx context.Context, pod *corev1.Pod, config *rest.Config, command ...string) (string, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", err
+	}
+	req := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.GetNamespace()).
+		Name(pod.GetName()).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "registry",
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, http.MethodPost, req.URL())
+	if err != nil {
+		return "", err
+	}
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: out, Stderr: errOut})
+	if err != nil {
+		return out.String(), fmt.Errorf("%s failed in local registry: %v %s", command[0], err, errOut.String())
+	}
+	return out.String(), nil
+}
+
+// Remote options of local registry, with its credentials and certificate when they are enabled
+func localRemoteOptions(ctx context.Context, client *kubernetes.Clientset) ([]remote.Option, error) {
+	options := []remote.Option{remote.WithContext(ctx)}
+
+	secret, err := secretClient(client).Get(ctx, secretName(DefaultLocalDomain), v1.GetOptions{})
+	if err == nil {
+		registry := &Registry{}
+		if auth, ok := registry.FromSecret(*secret).Config.Auths[DefaultLocalDomain]; ok {
+			options = append(options, remote.WithAuth(authn.FromConfig(authn.AuthConfig{
+				Username: auth.Username,
+				Password: auth.Password,
+			})))
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps(namespace.Namespace).Get(ctx, localCAName, v1.GetOptions{})
+	if err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(configMap.Data[localCAKey])) {
+			return nil, fmt.Errorf("invalid certificate of local registry in config map %s", localCAName)
+		}
+		transport := remote.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		options = append(options, remote.WithTransport(transport))
+	} else if !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	return options, nil
 }
 
 func getFreePort() (port int, err error) {
@@ -239,3 +651,15 @@ func getFreePort() (port int, err error) {
 	}
 	return
 }
+
+// Pod of local registry, nil if local registry is not installed
+func LocalRegistryPod(ctx context.Context, client *kubernetes.Clientset) (*corev1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace.Namespace).List(ctx, v1.ListOptions{Limit: 1, LabelSelector: "app=" + deployName})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	return &pods.Items[0], nil
+}
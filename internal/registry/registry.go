@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -12,10 +13,12 @@ import (
 	"github.com/kndpio/kndp/internal/namespace"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	kv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/cmd/create"
 	cfg "sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
@@ -24,25 +27,16 @@ const (
 	DefaultRemoteDomain = "xpkg.upbound.io"
 	LocalServiceName    = "registry"
 	DefaultLocalDomain  = LocalServiceName + "." + namespace.Namespace + ".svc.cluster.local"
+	LocalNodePort       = nodePort
 	AuthConfigLabel     = "kndp-registry-auth-config"
 )
 
-type RegistryAuth struct {
-	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	Server   string `json:"server" validate:"required,http_url"`
-}
-
-type RegistryConfig struct {
-	Auths map[string]RegistryAuth `json:"auths"`
-}
-
 type Registry struct {
-	Config  RegistryConfig
-	Default bool
-	Local   bool
-	Context string
+	Config       RegistryConfig
+	Default      bool
+	Local        bool
+	LocalOptions LocalOptions
+	Context      string
 	corev1.Secret
 }
 
@@ -61,27 +55,47 @@ func Registries(ctx context.Context, client *kubernetes.Clientset) ([]*Registry,
 	return registries, nil
 }
 
-// Creates new Registry by required parameters
-func New(server string, password string, username string, email string) Registry {
+// Creates new Registry with the same credentials for servers, first server is primary
+func New(servers []string, auth RegistryAuth) Registry {
 	registry := Registry{
 		Default: false,
 		Config: RegistryConfig{
-			Auths: map[string]RegistryAuth{
-				"server": {
-					Password: password,
-					Username: username,
-					Email:    email,
-					Server:   server,
-				},
-			},
+			Auths: map[string]RegistryAuth{},
 		},
 	}
-	registry.Annotations = map[string]string{
-		RegistryServerLabel: server,
+	for _, server := range servers {
+		host, err := registryHost(server)
+		if err != nil {
+			// Invalid server is reported by validation
+			host = server
+		}
+		registry.Config.Auths[host] = auth
+	}
+	if len(servers) > 0 {
+		registry.Annotations = map[string]string{
+			RegistryServerLabel: servers[0],
+		}
 	}
 	return registry
 }
 
+// Creates new Registry with credentials of servers from docker config and credential helpers
+func NewFromDockerConfig(servers []string) (Registry, error) {
+	registry := New(servers, RegistryAuth{})
+	for _, server := range servers {
+		auth, err := DockerConfigAuth(server)
+		if err != nil {
+			return registry, err
+		}
+		host, err := registryHost(server)
+		if err != nil {
+			return registry, err
+		}
+		registry.Config.Auths[host] = auth
+	}
+	return registry, nil
+}
+
 // Creates new local Registry
 func NewLocal() Registry {
 	registry := Registry{
@@ -94,13 +108,18 @@ func NewLocal() Registry {
 // Validate data in Registry object
 func (r *Registry) Validate(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
 	if r.Local {
-		return nil
+		return r.LocalOptions.Validate()
+	}
+	if len(r.Config.Auths) == 0 {
+		return fmt.Errorf("registry server is required")
 	}
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	for _, auth := range r.Config.Auths {
-		err := validate.Struct(auth)
+	for host, auth := range r.Config.Auths {
+		if _, err := registryHost(host); err != nil {
+			return err
+		}
+		err := validateAuth(auth)
 		if err != nil {
-			return fmt.Errorf(err.Error())
+			return fmt.Errorf("credentials of %s are invalid: %v", host, err)
 		}
 	}
 	if r.Exists(ctx, client) {
@@ -109,22 +128,63 @@ func (r *Registry) Validate(ctx context.Context, client *kubernetes.Clientset, l
 	return nil
 }
 
-// Check if registry in provided context exists
+func validateAuth(auth RegistryAuth) error {
+	return validator.New(validator.WithRequiredStructEnabled()).Struct(auth)
+}
+
+// Check if registry in provided context exists, registries with any common server are the same
 func (r *Registry) Exists(ctx context.Context, client *kubernetes.Clientset) bool {
 	registries, _ := Registries(ctx, client)
+	hosts := map[string]bool{}
+	for _, host := range r.Servers() {
+		hosts[host] = true
+	}
 	for _, registry := range registries {
-		for authServer := range r.Config.Auths {
-			if existsUrl := registry.Annotations[RegistryServerLabel]; existsUrl != "" && strings.Contains(existsUrl, authServer) {
+		for _, host := range registry.Servers() {
+			if hosts[host] {
 				return true
 			}
 		}
-		if existsUrl := registry.Annotations[RegistryServerLabel]; existsUrl != "" && strings.Contains(existsUrl, r.Annotations[RegistryServerLabel]) {
-			return true
-		}
 	}
 	return false
 }
 
+// Credentials of registry server
+func (r *Registry) Auth(server string) (RegistryAuth, bool) {
+	host, err := registryHost(server)
+	if err != nil {
+		return RegistryAuth{}, false
+	}
+	auth, ok := r.Config.Auths[host]
+	return auth, ok
+}
+
+// Hostnames of registry servers
+func (r *Registry) Servers() []string {
+	found := map[string]bool{}
+	servers := []string{}
+	add := func(server string) {
+		host, err := registryHost(server)
+		if err != nil || found[host] {
+			return
+		}
+		found[host] = true
+		servers = append(servers, host)
+	}
+	if server := r.Annotations[RegistryServerLabel]; server != "" {
+		add(server)
+	}
+	hosts := []string{}
+	for host := range r.Config.Auths {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		add(host)
+	}
+	return servers
+}
+
 // Creates registry in requested context and assign it to engine
 func (r *Registry) Create(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
 	var err error
@@ -146,37 +206,50 @@ func (r *Registry) Create(ctx context.Context, config *rest.Config, logger *zap.
 	}
 	release, _ := installer.GetRelease()
 
+	if release != nil && release.Config == nil {
+		release.Config = map[string]interface{}{}
+	}
+
 	if r.Local {
 		logger.Debug("Create Local Registry")
-		err := r.CreateLocal(ctx, client)
+		err := r.CreateLocal(ctx, client, logger)
 		if err != nil {
 			return err
 		}
+		if r.LocalOptions.Username != "" {
+			// Engine pulls packages from local registry with its credentials
+			r.Name = secretName(DefaultLocalDomain)
+			r.Annotations = map[string]string{RegistryServerLabel: DefaultLocalDomain}
+			r.Config = RegistryConfig{
+				Auths: map[string]RegistryAuth{
+					DefaultLocalDomain: NewAuth(r.LocalOptions.Username, r.LocalOptions.Password, ""),
+				},
+			}
+			err := r.applySecret(ctx, client)
+			if err != nil {
+				return err
+			}
+			if release != nil {
+				assignPullSecret(release.Config, r.Name)
+			}
+		}
+		if r.LocalOptions.TLS && release != nil {
+			release.Config["registryCaBundleConfig"] = map[string]interface{}{
+				"name": localCAName,
+				"key":  localCAKey,
+			}
+		}
 	} else {
 		logger.Debug("Create Registry")
 
-		r.Name = r.Domain()
-		serverUrls := []string{}
-		for _, auth := range r.Config.Auths {
-			serverUrls = append(
-				serverUrls,
-				strings.Replace(auth.Server, "https://", "", -1),
-			)
+		r.Name = secretName(r.Domain())
+		err := r.applySecret(ctx, client)
+		if err != nil {
+			return err
 		}
 
 		if release != nil {
-			if release.Config == nil {
-				release.Config = map[string]interface{}{
-					"imagePullSecrets": []interface{}{},
-				}
-			}
-			if release.Config["imagePullSecrets"] == nil {
-				release.Config["imagePullSecrets"] = []interface{}{}
-			}
-			release.Config["imagePullSecrets"] = append(
-				release.Config["imagePullSecrets"].([]interface{}),
-				r.Name,
-			)
+			assignPullSecret(release.Config, r.Name)
 		}
 
 	}
@@ -201,16 +274,51 @@ func (r *Registry) Create(ctx context.Context, config *rest.Config, logger *zap.
 
 	if installer != nil && release != nil {
 		logger.Debug("Upgrade Corssplane chart", "Values", release.Config)
-		return installer.Upgrade(engine.Version, release.Config)
+		return installer.Upgrade(engine.ReleaseVersion(release), release.Config)
 	} else {
 		logger.Warnf("Crossplane engine not found, in namespace %s", namespace.Namespace)
 	}
 	return nil
 }
 
+// Add secret to engine image pull secrets, if it isn't assigned yet
+func assignPullSecret(values map[string]interface{}, name string) {
+	pullSecrets, _ := values["imagePullSecrets"].([]interface{})
+	for _, secret := range pullSecrets {
+		if secret == name {
+			return
+		}
+	}
+	values["imagePullSecrets"] = append(pullSecrets, name)
+}
+
+// Create or update image pull secret of registry with credentials of its servers
+func (r *Registry) applySecret(ctx context.Context, client *kubernetes.Clientset) error {
+	auths := map[string]create.DockerConfigEntry{}
+	for host, auth := range r.Config.Auths {
+		auths[host] = create.DockerConfigEntry{
+			Username: auth.Username,
+			Password: auth.Password,
+			Email:    auth.Email,
+			Auth:     auth.Auth,
+		}
+	}
+	meta := metav1.ObjectMeta{
+		Name:        r.Name,
+		Labels:      engine.ManagedLabels(map[string]string{AuthConfigLabel: "true"}),
+		Annotations: r.Annotations,
+	}
+	return kube.NewImagePullApplicator(kube.NewSecretApplicator(client)).
+		ApplyConfig(ctx, meta, namespace.Namespace, auths)
+}
+
+// Registry from secret, credentials are read from docker config of secret
 func (r *Registry) FromSecret(sec corev1.Secret) *Registry {
 	secJson, _ := json.Marshal(sec)
 	json.Unmarshal(secJson, r)
+	if data, ok := sec.Data[corev1.DockerConfigJsonKey]; ok {
+		json.Unmarshal(data, &r.Config)
+	}
 	return r
 }
 
@@ -264,7 +372,7 @@ func (r *Registry) Delete(ctx context.Context, config *rest.Config, logger *zap.
 			}
 		}
 
-		err = installer.Upgrade(engine.Version, release.Config)
+		err = installer.Upgrade(engine.ReleaseVersion(release), release.Config)
 		if err != nil {
 			return err
 		}
@@ -282,8 +390,24 @@ func (r *Registry) Delete(ctx context.Context, config *rest.Config, logger *zap.
 	return secretClient(client).Delete(ctx, r.Name, metav1.DeleteOptions{})
 }
 
-// Copy registries from source to destination contexts
-func CopyRegistries(ctx context.Context, logger *zap.SugaredLogger, sourceConfig *rest.Config, destinationConfig *rest.Config) error {
+// Delete all registry secrets, engine is not updated
+func DeleteRegistries(ctx context.Context, client *kubernetes.Clientset, logger *zap.SugaredLogger) error {
+	registries, err := Registries(ctx, client)
+	if err != nil {
+		return err
+	}
+	for _, registry := range registries {
+		err := secretClient(client).Delete(ctx, registry.Name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		logger.Debugf("Registry %s deleted", registry.Annotations[RegistryServerLabel])
+	}
+	return nil
+}
+
+// Copy registries from source to destination contexts, only included by name are copied
+func CopyRegistries(ctx context.Context, logger *zap.SugaredLogger, sourceConfig *rest.Config, destinationConfig *rest.Config, include func(name string) bool) error {
 
 	destClient, err := kube.Client(destinationConfig)
 	if err != nil {
@@ -302,6 +426,10 @@ func CopyRegistries(ctx context.Context, logger *zap.SugaredLogger, sourceConfig
 
 	if len(registries) > 0 {
 		for _, registry := range registries {
+			if !include(registry.Name) {
+				logger.Debugf("Registry %s excluded, skipping.", registry.Name)
+				continue
+			}
 			if !registry.Exists(ctx, destClient) {
 				registry.SetResourceVersion("")
 				_, err = destClient.CoreV1().Secrets(namespace.Namespace).Create(ctx, registry.ToSecret(), metav1.CreateOptions{})
@@ -330,6 +458,11 @@ func (r *Registry) SetLocal(l bool) {
 	r.Local = l
 }
 
+// Options of local registry
+func (r *Registry) WithLocalOptions(o LocalOptions) {
+	r.LocalOptions = o
+}
+
 // Kubernetes context where registry will be created
 func (r *Registry) WithContext(c string) {
 	r.Context = c
@@ -340,9 +473,13 @@ func (r *Registry) Domain() string {
 	if r.Local {
 		return DefaultLocalDomain
 	}
-	domain := DefaultRemoteDomain
-	domain = strings.Split(r.Config.Auths["server"].Server, "/")[2]
-	return domain
+	if server := r.Annotations[RegistryServerLabel]; server != "" {
+		return ServerHost(server)
+	}
+	if servers := r.Servers(); len(servers) > 0 {
+		return servers[0]
+	}
+	return DefaultRemoteDomain
 }
 
 func secretClient(client *kubernetes.Clientset) kv1.SecretInterface {
@@ -0,0 +1,229 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	crossv1 "github.com/crossplane/crossplane/apis/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/kndpio/kndp/internal/engine"
+	"github.com/kndpio/kndp/internal/kube"
+	"go.uber.org/zap"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	cfg "sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// Annotation of packages, changed to make package manager resolve packages again
+const CredentialsUpdatedAnnotation = "kndp.io/registry-credentials-updated"
+
+var (
+	packageResources = []schema.GroupVersionResource{
+		crossv1.SchemeGroupVersion.WithResource("providers"),
+		crossv1.SchemeGroupVersion.WithResource("configurations"),
+	}
+	revisionResources = []schema.GroupVersionResource{
+		crossv1.SchemeGroupVersion.WithResource("providerrevisions"),
+		crossv1.SchemeGroupVersion.WithResource("configurationrevisions"),
+	}
+)
+
+// Get registry by name of its secret
+func Get(ctx context.Context, client *kubernetes.Clientset, name string) (*Registry, error) {
+	secret, err := secretClient(client).Get(ctx, name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, fmt.Errorf("registry %s not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret.Labels[AuthConfigLabel] != "true" {
+		return nil, fmt.Errorf("secret %s is not a registry", name)
+	}
+	registry := &Registry{}
+	return registry.FromSecret(*secret), nil
+}
+
+// Replace password of servers, all servers of registry are updated when none are provided.
+// Username and email of servers are kept, when they aren't provided.
+func (r *Registry) SetCredentials(servers []string, username string, password string, email string) error {
+	hosts, err := r.updatedHosts(servers)
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		current := r.Config.Auths[host]
+		auth := NewAuth(
+			valueOrDefault(username, current.Username, TokenUsername),
+			password,
+			valueOrDefault(email, current.Email, ""),
+		)
+		r.Config.Auths[host] = auth
+	}
+	return nil
+}
+
+// Replace credentials of servers with credentials from docker config and credential helpers
+func (r *Registry) SetDockerConfigCredentials(servers []string) error {
+	hosts, err := r.updatedHosts(servers)
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		auth, err := DockerConfigAuth(host)
+		if err != nil {
+			return err
+		}
+		r.Config.Auths[host] = auth
+	}
+	return nil
+}
+
+// Update credentials of registry secret in place, packages pulled from registry are resolved again
+func (r *Registry) Update(ctx context.Context, config *rest.Config, logger *zap.SugaredLogger) error {
+	var err error
+	if r.Context != "" {
+		config, err = cfg.GetConfigWithContext(r.Context)
+		if err != nil {
+			return err
+		}
+	}
+	client, err := kube.Client(config)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	for host, auth := range r.Config.Auths {
+		if err := validateAuth(auth); err != nil {
+			return fmt.Errorf("credentials of %s are invalid: %v", host, err)
+		}
+	}
+	logger.Debugf("Update registry secret %s", r.Name)
+	err = r.applySecret(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	defaultRegistry := DefaultRemoteDomain
+	if installer, err := engine.GetEngine(config); err == nil {
+		if release, err := installer.GetRelease(); err == nil && release.Config != nil {
+			if args, ok := release.Config["args"].([]interface{}); ok {
+				for _, arg := range args {
+					if value, ok := arg.(string); ok && strings.HasPrefix(value, "--registry=") {
+						defaultRegistry = strings.TrimPrefix(value, "--registry=")
+					}
+				}
+			}
+		}
+	}
+	return r.refreshPackages(ctx, dynamicClient, defaultRegistry, logger)
+}
+
+// Annotate packages of registry servers and delete their unhealthy revisions,
+// so package manager pulls them again with updated credentials
+func (r *Registry) refreshPackages(ctx context.Context, dynamicClient dynamic.Interface, defaultRegistry string, logger *zap.SugaredLogger) error {
+	hosts := map[string]bool{}
+	for _, host := range r.Servers() {
+		hosts[host] = true
+	}
+	pulledFrom := func(image string) bool {
+		ref, err := name.ParseReference(image, name.WithDefaultRegistry(defaultRegistry))
+		return err == nil && hosts[ref.Context().RegistryStr()]
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, CredentialsUpdatedAnnotation, time.Now().UTC().Format(time.RFC3339)))
+	for _, gvr := range packageResources {
+		packages, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, pkg := range packages.Items {
+			image, _, _ := unstructured.NestedString(pkg.Object, "spec", "package")
+			if !pulledFrom(image) {
+				continue
+			}
+			logger.Infof("Resolving %s %s again", pkg.GetKind(), pkg.GetName())
+			_, err := dynamicClient.Resource(gvr).Patch(ctx, pkg.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, gvr := range revisionResources {
+		revisions, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, revision := range revisions.Items {
+			image, _, _ := unstructured.NestedString(revision.Object, "spec", "image")
+			if !pulledFrom(image) || revisionHealthy(revision) {
+				continue
+			}
+			logger.Infof("Restarting unhealthy %s %s", revision.GetKind(), revision.GetName())
+			err := dynamicClient.Resource(gvr).Delete(ctx, revision.GetName(), metav1.DeleteOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Hosts of registry to be updated, provided servers must belong to registry
+func (r *Registry) updatedHosts(servers []string) ([]string, error) {
+	if len(servers) == 0 {
+		hosts := []string{}
+		for host := range r.Config.Auths {
+			hosts = append(hosts, host)
+		}
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("registry %s has no servers", r.Name)
+		}
+		return hosts, nil
+	}
+	hosts := []string{}
+	for _, server := range servers {
+		host, err := registryHost(server)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := r.Config.Auths[host]; !ok {
+			return nil, fmt.Errorf("server %s not found in registry %s", server, r.Name)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+func revisionHealthy(revision unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(revision.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == string(crossv1.TypeHealthy) {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
+
+func valueOrDefault(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -308,11 +308,63 @@ func ApplyResources(ctx context.Context, client *dynamic.DynamicClient, logger *
 	return nil
 }
 
-func CopyComposites(ctx context.Context, logger *zap.SugaredLogger, sourceContext dynamic.Interface, destinationContext dynamic.Interface) error {
+func CopyComposites(ctx context.Context, logger *zap.SugaredLogger, sourceContext dynamic.Interface, destinationContext dynamic.Interface, include func(name string) bool) error {
 
 	//Get composite resources from XRDs definition and apply them
+	composites, err := ManagedComposites(ctx, sourceContext)
+	if err != nil {
+		return err
+	}
+
+	if len(composites) == 0 {
+		logger.Warn("Composite resources not found")
+		return nil
+	}
+	for _, composite := range composites {
+		xr := composite.Object
+		if !include(xr.GetName()) {
+			logger.Debugf("Resource %s excluded, skipping.", xr.GetName())
+			continue
+		}
+		xr.SetResourceVersion("")
+		xr.SetFinalizers(nil)
+		resourceId := composite.ResourceId()
+
+		_, err = destinationContext.Resource(resourceId).Namespace("").Get(ctx, xr.GetName(), metav1.GetOptions{})
+		if err != nil {
+			_, err = destinationContext.Resource(resourceId).Namespace("").Create(ctx, &xr, metav1.CreateOptions{})
+			if err != nil {
+				logger.Warn(err)
+			} else {
+				logger.Infof("Resource created successfully %s", xr.GetName())
+			}
+		} else {
+			logger.Warnf("Resource %s with type %s already exists, skipping.", xr.GetName(), resourceId.GroupResource().String())
+		}
+	}
+	return nil
+}
+
+// Composite resource with API resource it is served by
+type Composite struct {
+	Group    string                    `json:"group"`
+	Version  string                    `json:"version"`
+	Resource string                    `json:"resource"`
+	Object   unstructured.Unstructured `json:"object"`
+}
+
+func (c *Composite) ResourceId() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.Group,
+		Version:  c.Version,
+		Resource: c.Resource,
+	}
+}
+
+// Composite resources managed by kndp, listed by referenceable version of each XRD
+func ManagedComposites(ctx context.Context, dynamicClient dynamic.Interface) ([]Composite, error) {
 	XRDs, err := kube.GetKubeResources(kube.ResourceParams{
-		Dynamic:    sourceContext,
+		Dynamic:    dynamicClient,
 		Ctx:        ctx,
 		Group:      "apiextensions.crossplane.io",
 		Version:    "v1",
@@ -321,57 +373,55 @@ func CopyComposites(ctx context.Context, logger *zap.SugaredLogger, sourceContex
 		ListOption: metav1.ListOptions{},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(XRDs) > 0 {
-		for _, xrd := range XRDs {
-			var paramsXRs v1.CompositeResourceDefinition
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(xrd.UnstructuredContent(), &paramsXRs); err != nil {
-				logger.Infof("Failed to convert item %s: %v\n", xrd.GetName(), err)
-				return nil
-			}
-			for _, version := range paramsXRs.Spec.Versions {
-				XRs, err := kube.GetKubeResources(kube.ResourceParams{
-					Dynamic:   sourceContext,
-					Ctx:       ctx,
-					Group:     paramsXRs.Spec.Group,
-					Version:   version.Name,
-					Resource:  paramsXRs.Spec.Names.Plural,
-					Namespace: "",
-					ListOption: metav1.ListOptions{
-						LabelSelector: "app.kubernetes.io/managed-by=kndp",
-					},
-				})
-				if err != nil {
-					return err
-				}
-
-				for _, xr := range XRs {
-					xr.SetResourceVersion("")
-					xr.SetFinalizers(nil)
-					resourceId := schema.GroupVersionResource{
-						Group:    paramsXRs.Spec.Group,
-						Version:  version.Name,
-						Resource: paramsXRs.Spec.Names.Plural,
-					}
-
-					_, err = destinationContext.Resource(resourceId).Namespace("").Get(ctx, xr.GetName(), metav1.GetOptions{})
-					if err != nil {
-						_, err = destinationContext.Resource(resourceId).Namespace("").Create(ctx, &xr, metav1.CreateOptions{})
-						if err != nil {
-							logger.Warn(err)
-						} else {
-							logger.Infof("Resource created successfully %s", xr.GetName())
-						}
-					} else {
-						logger.Warnf("Resource %s with type %s already exists, skipping.", xr.GetName(), resourceId.GroupResource().String())
-					}
-				}
+	composites := []Composite{}
+	for _, xrd := range XRDs {
+		var paramsXRs v1.CompositeResourceDefinition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(xrd.UnstructuredContent(), &paramsXRs); err != nil {
+			return nil, err
+		}
+		version := ""
+		for _, v := range paramsXRs.Spec.Versions {
+			if v.Referenceable || version == "" {
+				version = v.Name
 			}
 		}
-	} else {
-		logger.Warn("Composite resources not found")
+		XRs, err := kube.GetKubeResources(kube.ResourceParams{
+			Dynamic:   dynamicClient,
+			Ctx:       ctx,
+			Group:     paramsXRs.Spec.Group,
+			Version:   version,
+			Resource:  paramsXRs.Spec.Names.Plural,
+			Namespace: "",
+			ListOption: metav1.ListOptions{
+				LabelSelector: engine.ManagedSelector(nil),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, xr := range XRs {
+			composites = append(composites, Composite{
+				Group:    paramsXRs.Spec.Group,
+				Version:  version,
+				Resource: paramsXRs.Spec.Names.Plural,
+				Object:   xr,
+			})
+		}
 	}
-	return nil
+	return composites, nil
+}
+
+// Remove cluster specific metadata and status, so object could be created in another cluster
+func CleanObject(u *unstructured.Unstructured) {
+	u.SetResourceVersion("")
+	u.SetUID("")
+	u.SetGeneration(0)
+	u.SetCreationTimestamp(metav1.Time{})
+	u.SetManagedFields(nil)
+	u.SetOwnerReferences(nil)
+	u.SetFinalizers(nil)
+	unstructured.RemoveNestedField(u.Object, "status")
 }